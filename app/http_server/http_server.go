@@ -1,6 +1,7 @@
 package http_server
 
 import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/openapi"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -9,32 +10,62 @@ func (s *HttpServer) Use(args ...interface{}) {
 	s.fiber.Use(args...)
 }
 
+// App return the Fiber app serving the HTTP routes, e.g. for app.Test()
+func (s *HttpServer) App() *fiber.App {
+	return s.fiber
+}
+
+// OpenAPI return the registry of the routes registered through the server
+// and its groups, it builds the OpenAPI document
+func (s *HttpServer) OpenAPI() *openapi.Registry {
+	return s.openapi
+}
+
+// Storage return the storage of the middleware name, its state is shared by
+// the processes and replicas through Redis. It is nil without Redis, the
+// middleware then keeps its state in memory.
+func (s *HttpServer) Storage(name string) fiber.Storage {
+	if s.storage == nil {
+		return nil
+	}
+	return s.storage.Prefixed(name)
+}
+
 // For Fiber route grouping
-func (s *HttpServer) Group(prefix string, handlers ...func(*fiber.Ctx) error) fiber.Router {
-	return s.fiber.Group(prefix, handlers...)
+func (s *HttpServer) Group(prefix string, handlers ...func(*fiber.Ctx) error) *Group {
+	return &Group{
+		router:  s.fiber.Group(prefix, handlers...),
+		prefix:  prefix,
+		openapi: s.openapi,
+	}
 }
 
 // GET register service endpoint for HTTP GET
-func (s *HttpServer) GET(path string, handlers ...func(*fiber.Ctx) error) fiber.Router {
-	return s.fiber.Get(path, handlers...)
+func (s *HttpServer) GET(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	s.fiber.Get(path, handlers...)
+	return s.openapi.Add(fiber.MethodGet, path)
 }
 
 // POST register service endpoint for HTTP POST
-func (s *HttpServer) POST(path string, handlers ...func(*fiber.Ctx) error) fiber.Router {
-	return s.fiber.Post(path, handlers...)
+func (s *HttpServer) POST(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	s.fiber.Post(path, handlers...)
+	return s.openapi.Add(fiber.MethodPost, path)
 }
 
 // PUT register service endpoint for HTTP PUT
-func (s *HttpServer) PUT(path string, handlers ...func(*fiber.Ctx) error) fiber.Router {
-	return s.fiber.Put(path, handlers...)
+func (s *HttpServer) PUT(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	s.fiber.Put(path, handlers...)
+	return s.openapi.Add(fiber.MethodPut, path)
 }
 
 // PATCH register service endpoint for HTTP PATCH
-func (s *HttpServer) PATCH(path string, handlers ...func(*fiber.Ctx) error) fiber.Router {
-	return s.fiber.Patch(path, handlers...)
+func (s *HttpServer) PATCH(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	s.fiber.Patch(path, handlers...)
+	return s.openapi.Add(fiber.MethodPatch, path)
 }
 
 // DELETE register service endpoint for HTTP DELETE
-func (s *HttpServer) DELETE(path string, handlers ...func(*fiber.Ctx) error) fiber.Router {
-	return s.fiber.Delete(path, handlers...)
+func (s *HttpServer) DELETE(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	s.fiber.Delete(path, handlers...)
+	return s.openapi.Add(fiber.MethodDelete, path)
 }
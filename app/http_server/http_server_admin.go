@@ -0,0 +1,36 @@
+package http_server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newAdminServer create the internal admin server, it has its own middleware
+// chain and never runs in prefork children
+func (s *HttpServer) newAdminServer() {
+	adminConfig := s.fiberConfig()
+	adminConfig.Prefork = false
+	adminConfig.DisableStartupMessage = true
+	adminConfig.AppName = s.Config().App.AppName + " (admin)"
+
+	s.admin = fiber.New(adminConfig)
+}
+
+// Admin return the internal admin server, nil when ADMIN_PORT is not set
+func (s *HttpServer) Admin() *fiber.App {
+	return s.admin
+}
+
+// startAdmin will start the admin service, this function will block thread
+func (s *HttpServer) startAdmin() error {
+	addr := fmt.Sprintf("%s:%d", s.Config().Admin.AdminHost, s.Config().Admin.AdminPort)
+	s.Log("AdminServer", fmt.Sprintf("Admin service is running on %s", addr))
+	return s.admin.Listen(addr)
+}
+
+// stopAdmin will stop the admin service graceful shutdown
+func (s *HttpServer) stopAdmin(ctx context.Context) error {
+	return s.admin.ShutdownWithContext(ctx)
+}
@@ -2,114 +2,234 @@ package http_server
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
-	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
-	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
-	"github.com/getsentry/sentry-go"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/certs"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/component"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/health"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/openapi"
 	"github.com/gofiber/fiber/v2"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
-	"gorm.io/gorm"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
 )
 
 type (
 	IHttpServer interface {
+		Config() *config.Config
 		StartServer() error
 		StopServer()
 		Cleanup() error
+		Reload() error
+		Draining() bool
+		Admin() *fiber.App
+		Liveness() *health.Probe
+		Readiness() *health.Probe
+		Startup() *health.Probe
 		Log(tag string, message string)
 
-		startHTTP(exitChannel chan bool) error
-		stopHTTP()
+		startHTTP() error
+		stopHTTP(ctx context.Context) error
+		startGRPC() error
+		stopGRPC(ctx context.Context) error
+		startAdmin() error
+		stopAdmin(ctx context.Context) error
 
 		// HTTP Services
 		Use(args ...interface{})
-		Group(prefix string, h ...func(ctx *fiber.Ctx) error) fiber.Router
+		Group(prefix string, h ...func(ctx *fiber.Ctx) error) *Group
+		OpenAPI() *openapi.Registry
 
 		// HTTP Methods
-		GET(path string, h ...func(ctx *fiber.Ctx) error) fiber.Router
-		POST(path string, h ...func(ctx *fiber.Ctx) error) fiber.Router
-		PUT(path string, h ...func(ctx *fiber.Ctx) error) fiber.Router
-		PATCH(path string, h ...func(ctx *fiber.Ctx) error) fiber.Router
-		DELETE(path string, h ...func(ctx *fiber.Ctx) error) fiber.Router
+		GET(path string, h ...func(ctx *fiber.Ctx) error) *openapi.Route
+		POST(path string, h ...func(ctx *fiber.Ctx) error) *openapi.Route
+		PUT(path string, h ...func(ctx *fiber.Ctx) error) *openapi.Route
+		PATCH(path string, h ...func(ctx *fiber.Ctx) error) *openapi.Route
+		DELETE(path string, h ...func(ctx *fiber.Ctx) error) *openapi.Route
+
+		// gRPC Services
+		RegisterService(desc *grpc.ServiceDesc, impl interface{})
+		UseGRPC(unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor)
 	}
 	// HttpServer implement IHttpServer it is context for HTTP service
 	HttpServer struct {
-		Config        *config.Config
-		fiber         *fiber.App
-		DbClient      *gorm.DB
-		Cacher        *cache.Cache
-		traceProvider *sdktrace.TracerProvider
-		Tracer        trace.Tracer
-		exitChannel   chan bool
+		// Running config, replaced as a whole on reload
+		config      atomic.Pointer[config.Config]
+		fiber       *fiber.App
+		grpc        *grpc.Server
+		grpcHealth  *grpchealth.Server
+		grpcUnary   []grpc.UnaryServerInterceptor
+		grpcStream  []grpc.StreamServerInterceptor
+		admin       *fiber.App
+		openapi     *openapi.Registry
+		certs       *certs.Reloader
+		Components  *component.Registry
+		Cacher      cache.Cacher
+		storage     *cache.Storage
+		Tracer      trace.Tracer
+		exitChannel chan bool
+		reloadMu    sync.Mutex
+		reloadHooks []func(config *config.Config)
+		// Called on every reload, even when no setting changed
+		refreshHooks []func()
+		draining     atomic.Bool
+		liveness     *health.Probe
+		readiness    *health.Probe
+		startup      *health.Probe
+		// Prefork children, only tracked by the parent process
+		children      map[int]bool
+		childrenMu    sync.Mutex
+		drained       chan int
+		drainListener net.Listener
 	}
 )
 
 // NewHttpServer is the constructor function for HttpServer
 func NewHttpServer(
 	config *config.Config,
-	dbClient *gorm.DB,
-	cacher *cache.Cache,
-	traceProvider *sdktrace.TracerProvider,
+	components *component.Registry,
+	cacher cache.Cacher,
+	storage *cache.Storage,
 	tracer trace.Tracer,
 ) *HttpServer {
-	return &HttpServer{
-		Config:        config,
-		fiber:         fiber.New(config.Fiber.Config),
-		DbClient:      dbClient,
-		Cacher:        cacher,
-		traceProvider: traceProvider,
-		Tracer:        tracer,
+	s := &HttpServer{
+		Components: components,
+		Cacher:     cacher,
+		storage:    storage,
+		Tracer:     tracer,
+	}
+	s.config.Store(config)
+	s.fiber = fiber.New(s.fiberConfig())
+	s.openapi = openapi.NewRegistry(openapi.Info{Title: config.App.AppName, Version: "1.0.0"})
+	s.openapi.Errors(ErrorResponse{}, "Error")
+	s.applyRuntimeConfig()
+	s.newProbes()
+
+	if config.TLS.Enabled() {
+		s.newCertificates()
+	}
+
+	if config.App.GrpcEnabled {
+		s.newGRPCServer()
 	}
+
+	if config.Admin.AdminPort > 0 {
+		s.newAdminServer()
+	}
+
+	return s
+}
+
+// Config return the running config, its reloadable values are the ones of the
+// last reload. The config is never modified, keep the returned pointer for a
+// consistent view.
+func (s *HttpServer) Config() *config.Config {
+	return s.config.Load()
 }
 
 // Start start all registered services
 func (s *HttpServer) StartServer() error {
+	// With prefork the parent keeps track of its children, it relays SIGHUP to
+	// them and waits for them to drain before exiting
+	if s.isPreforkParent() {
+		s.trackChildren()
+		s.watchDrains()
+	}
+
+	// The prefork children serve their metrics to the parent, it runs the admin
+	// server
+	if fiber.IsChild() && s.admin != nil {
+		if listener := s.serveChildMetrics(); listener != nil {
+			defer listener.Close()
+		}
+	}
 
 	httpN := len(s.fiber.Stack())
-	var exitHTTP chan bool
+	var httpDone chan error
 	if httpN > 0 {
-		exitHTTP = make(chan bool, 1)
+		httpDone = make(chan error, 1)
+		go func() {
+			httpDone <- s.startHTTP()
+		}()
+	}
+
+	// With prefork the gRPC server only runs in the parent process, children
+	// cannot share its port
+	var grpcDone chan error
+	if s.grpc != nil && !fiber.IsChild() {
+		grpcDone = make(chan error, 1)
+		go func() {
+			grpcDone <- s.startGRPC()
+		}()
+	}
+
+	// The admin server only runs in the parent process as well
+	var adminDone chan error
+	if s.admin != nil && !fiber.IsChild() {
+		adminDone = make(chan error, 1)
 		go func() {
-			s.startHTTP(exitHTTP)
+			adminDone <- s.startAdmin()
 		}()
 	}
 
-	// There are 2 ways to exit from Microservices
+	// Reload the config and the TLS certificate when their files change
+	stopWatch := make(chan bool)
+	defer close(stopWatch)
+	go s.watchConfig(stopWatch)
+	go s.watchCertificates(stopWatch)
+
+	// There are 3 ways to exit from Microservices
 	// 1. The SigTerm can be send from outside program such as from k8s
 	// 2. Send true to ms.exitChannel
+	// 3. The HTTP, gRPC or admin server stops on its own, e.g. the port is in use
+	// SIGHUP reloads the config without exiting
 	osQuit := make(chan os.Signal, 1)
+	osReload := make(chan os.Signal, 1)
 	s.exitChannel = make(chan bool, 1)
 	signal.Notify(osQuit, syscall.SIGTERM, syscall.SIGINT)
+	signal.Notify(osReload, syscall.SIGHUP)
 	exit := false
 	for {
 		if exit {
 			break
 		}
 		select {
-		case <-osQuit:
-			// Exit from HTTP as well
-			if exitHTTP != nil {
-				exitHTTP <- true
+		case <-osReload:
+			s.Reload()
+			// The children serve the requests, they reload on their own
+			s.signalChildren(syscall.SIGHUP)
+		case sig := <-osQuit:
+			if !fiber.IsChild() {
+				s.Log("HttpServer", fmt.Sprintf("Received %s", sig))
 			}
 			exit = true
 		case <-s.exitChannel:
-			// Exit from HTTP as well
-			if exitHTTP != nil {
-				exitHTTP <- true
-			}
+			exit = true
+		case err := <-httpDone:
+			s.Log("HttpServer", fmt.Sprintf("HTTP service stopped: %v", err))
+			httpDone = nil
+			exit = true
+		case err := <-grpcDone:
+			s.Log("GrpcServer", fmt.Sprintf("gRPC service stopped: %v", err))
+			grpcDone = nil
+			exit = true
+		case err := <-adminDone:
+			s.Log("AdminServer", fmt.Sprintf("Admin service stopped: %v", err))
+			adminDone = nil
 			exit = true
 		}
 	}
 
-	// Run cleanup
-	s.Cleanup()
+	s.shutdown(httpDone != nil, grpcDone != nil, adminDone != nil)
 	return nil
 }
 
@@ -121,35 +241,32 @@ func (s *HttpServer) StopServer() {
 	s.exitChannel <- true
 }
 
-// Cleanup clean resources up from every registered services before exit
+// Draining report whether the server is shutting down, readiness checks must
+// fail while it is
+func (s *HttpServer) Draining() bool {
+	return s.draining.Load()
+}
+
+// Cleanup clean resources up from every registered services before exit, the
+// components are stopped in the reverse order of their start
 func (s *HttpServer) Cleanup() error {
 	if !fiber.IsChild() {
 		s.Log("HttpServer", "Start cleanup, close all client connections...")
 	}
 
-	// Close all database connection
-	if s.DbClient != nil {
-		sqlDB, _ := s.DbClient.DB()
-		sqlDB.Close()
-	}
-
-	// Close all redis connection
-	if s.Cacher != nil {
-		s.Cacher.Close()
-	}
-
-	// Sentry: Flush buffered events before the program terminates.
-	if s.Config.Sentry.SentryDSN != "" {
-		sentry.Flush(2 * time.Second)
-		sentry.Recover()
+	var err error
+	if s.Components != nil {
+		if err = s.Components.Stop(context.Background()); err != nil {
+			s.Log("HttpServer", fmt.Sprintf("[pid %d] Cleanup failed: %v", os.Getpid(), err))
+		}
 	}
 
-	// Close all tracer connection
-	if s.traceProvider != nil {
-		tracing.Cleanup(s.traceProvider)
+	// Stop listening for prefork children drain reports
+	if s.drainListener != nil {
+		s.drainListener.Close()
 	}
 
-	return nil
+	return err
 }
 
 // Log message to console
@@ -167,22 +284,21 @@ func (s *HttpServer) Log(tag string, message string) {
 }
 
 // startHTTP will start HTTP service, this function will block thread
-func (s *HttpServer) startHTTP(exitChannel chan bool) error {
-	// Caller can exit by sending value to exitChannel
-	go func() {
-		<-exitChannel
-		s.stopHTTP()
-	}()
-
-	return s.fiber.Listen(":" + s.Config.App.HttpPort)
+func (s *HttpServer) startHTTP() error {
+	addr := fmt.Sprintf(":%d", s.Config().App.HttpPort)
+	if s.certs != nil {
+		return s.listenTLS(addr)
+	}
+	return s.fiber.Listen(addr)
 }
 
-// stopHTTP will stop HTTP service graceful shutdown
-func (s *HttpServer) stopHTTP() {
-	_, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if !fiber.IsChild() {
-		s.Log("HttpServer", "Shutting down...")
+// stopHTTP will stop HTTP service graceful shutdown, the listener is closed
+// then in-flight requests have until the ctx deadline to complete
+func (s *HttpServer) stopHTTP(ctx context.Context) error {
+	// The prefork parent does not serve requests, its children drain on their own
+	if s.isPreforkParent() {
+		return s.waitChildren(ctx)
 	}
-	s.fiber.Shutdown()
+
+	return s.fiber.ShutdownWithContext(ctx)
 }
@@ -0,0 +1,52 @@
+package http_server
+
+import (
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/openapi"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorResponse is the body of the error responses, the violations list what
+// does not match the OpenAPI document
+type ErrorResponse struct {
+	Message    string              `json:"message"`
+	RequestID  string              `json:"request_id"`
+	Violations []openapi.Violation `json:"violations,omitempty"`
+}
+
+// errorHandler answer the errors returned by the handlers and middlewares
+// with a JSON body carrying the request ID, e.g.
+// {"message":"rate limit exceeded","request_id":"..."}
+func errorHandler(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	var (
+		fiberErr      *fiber.Error
+		validationErr *openapi.ValidationError
+		violations    []openapi.Violation
+	)
+	if errors.As(err, &fiberErr) {
+		code = fiberErr.Code
+	}
+	if errors.As(err, &validationErr) {
+		code = validationErr.Code
+		violations = validationErr.Violations
+	}
+
+	return c.Status(code).JSON(ErrorResponse{
+		Message:    err.Error(),
+		RequestID:  requestid.FromContext(c.UserContext()),
+		Violations: violations,
+	})
+}
+
+// fiberConfig return the Fiber config of the servers, with errorHandler
+// unless one is configured
+func (s *HttpServer) fiberConfig() fiber.Config {
+	fiberConfig := s.Config().Fiber.Config
+	if fiberConfig.ErrorHandler == nil {
+		fiberConfig.ErrorHandler = errorHandler
+	}
+	return fiberConfig
+}
@@ -0,0 +1,58 @@
+package http_server
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Group is a group of routes sharing a path prefix and handlers, its routes
+// are documented like the routes of the server
+type Group struct {
+	router  fiber.Router
+	prefix  string
+	openapi *openapi.Registry
+}
+
+// Use register middlewares running for the routes of the group
+func (g *Group) Use(args ...interface{}) {
+	g.router.Use(args...)
+}
+
+// Group return a nested group
+func (g *Group) Group(prefix string, handlers ...func(*fiber.Ctx) error) *Group {
+	return &Group{
+		router:  g.router.Group(prefix, handlers...),
+		prefix:  g.prefix + prefix,
+		openapi: g.openapi,
+	}
+}
+
+// GET register service endpoint for HTTP GET
+func (g *Group) GET(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	g.router.Get(path, handlers...)
+	return g.openapi.Add(fiber.MethodGet, g.prefix+path)
+}
+
+// POST register service endpoint for HTTP POST
+func (g *Group) POST(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	g.router.Post(path, handlers...)
+	return g.openapi.Add(fiber.MethodPost, g.prefix+path)
+}
+
+// PUT register service endpoint for HTTP PUT
+func (g *Group) PUT(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	g.router.Put(path, handlers...)
+	return g.openapi.Add(fiber.MethodPut, g.prefix+path)
+}
+
+// PATCH register service endpoint for HTTP PATCH
+func (g *Group) PATCH(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	g.router.Patch(path, handlers...)
+	return g.openapi.Add(fiber.MethodPatch, g.prefix+path)
+}
+
+// DELETE register service endpoint for HTTP DELETE
+func (g *Group) DELETE(path string, handlers ...func(*fiber.Ctx) error) *openapi.Route {
+	g.router.Delete(path, handlers...)
+	return g.openapi.Add(fiber.MethodDelete, g.prefix+path)
+}
@@ -0,0 +1,125 @@
+package http_server
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/exceptions"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/requestid"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// newGRPCServer create the gRPC server with health checking, reflection, TLS
+// and the request ID/OpenTelemetry/Sentry interceptors, then the ones of
+// UseGRPC
+func (s *HttpServer) newGRPCServer() {
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			requestid.UnaryServerInterceptor(),
+			exceptions.SentryUnaryServerInterceptor(),
+			tracing.UnaryServerInterceptor(s.Tracer),
+			s.unaryInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			requestid.StreamServerInterceptor(),
+			exceptions.SentryStreamServerInterceptor(),
+			s.streamInterceptor,
+		),
+	}
+	// Same certificate and client verification as the HTTP server
+	if s.certs != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(s.tlsConfig("h2"))))
+	}
+	s.grpc = grpc.NewServer(options...)
+
+	s.grpcHealth = health.NewServer()
+	healthpb.RegisterHealthServer(s.grpc, s.grpcHealth)
+
+	if s.Config().App.GrpcReflection {
+		reflection.Register(s.grpc)
+	}
+}
+
+// RegisterService register a gRPC service implementation, it does nothing when
+// the gRPC server is disabled
+func (s *HttpServer) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	if s.grpc == nil {
+		return
+	}
+	s.grpc.RegisterService(desc, impl)
+	s.grpcHealth.SetServingStatus(desc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+}
+
+// GRPC return the gRPC server, nil when it is disabled
+func (s *HttpServer) GRPC() *grpc.Server {
+	return s.grpc
+}
+
+// UseGRPC add interceptors to the gRPC calls, e.g. the authentication, they
+// run in order after the built-in ones. Like the services, they are added
+// before StartServer.
+func (s *HttpServer) UseGRPC(unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) {
+	if unary != nil {
+		s.grpcUnary = append(s.grpcUnary, unary)
+	}
+	if stream != nil {
+		s.grpcStream = append(s.grpcStream, stream)
+	}
+}
+
+// unaryInterceptor run the interceptors of UseGRPC
+func (s *HttpServer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	for i := len(s.grpcUnary) - 1; i >= 0; i-- {
+		interceptor, next := s.grpcUnary[i], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor run the interceptors of UseGRPC
+func (s *HttpServer) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	for i := len(s.grpcStream) - 1; i >= 0; i-- {
+		interceptor, next := s.grpcStream[i], handler
+		handler = func(srv interface{}, stream grpc.ServerStream) error {
+			return interceptor(srv, stream, info, next)
+		}
+	}
+	return handler(srv, stream)
+}
+
+// startGRPC will start gRPC service, this function will block thread
+func (s *HttpServer) startGRPC() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.Config().App.GrpcPort))
+	if err != nil {
+		return fmt.Errorf("cannot listen on port %d: %w", s.Config().App.GrpcPort, err)
+	}
+
+	s.Log("GrpcServer", fmt.Sprintf("gRPC service is running on port %d", s.Config().App.GrpcPort))
+	return s.grpc.Serve(listener)
+}
+
+// stopGRPC will stop gRPC service graceful shutdown, in-flight calls are
+// forcibly closed when the ctx deadline is exceeded
+func (s *HttpServer) stopGRPC(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,46 @@
+package http_server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/health"
+)
+
+// newProbes create the liveness, readiness and startup probes. Liveness does
+// not check the dependencies, an outage must not restart every instance.
+// Readiness fails while draining, the dependency checkers are registered on
+// top of it with Readiness().Register.
+func (s *HttpServer) newProbes() {
+	opts := []health.ProbeOption{
+		health.WithCacheTTL(s.Config().Health.HealthCacheTTL),
+		health.WithTimeout(s.Config().Health.HealthCheckTimeout),
+	}
+
+	s.liveness = health.NewProbe("liveness", opts...)
+	s.readiness = health.NewProbe("readiness", opts...)
+	s.startup = health.NewProbe("startup", append(opts, health.Latched())...)
+
+	s.readiness.Register(health.NewChecker("draining", func(ctx context.Context) error {
+		if s.Draining() {
+			return errors.New("server is shutting down")
+		}
+		return nil
+	}))
+}
+
+// Liveness return the probe telling whether the process must be restarted
+func (s *HttpServer) Liveness() *health.Probe {
+	return s.liveness
+}
+
+// Readiness return the probe telling whether the instance can serve requests
+func (s *HttpServer) Readiness() *health.Probe {
+	return s.readiness
+}
+
+// Startup return the probe telling whether the instance finished starting, it
+// keeps succeeding once it succeeded
+func (s *HttpServer) Startup() *health.Probe {
+	return s.startup
+}
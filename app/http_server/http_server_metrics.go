@@ -0,0 +1,53 @@
+package http_server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// With prefork the children serve the HTTP requests and count them, while the
+// admin server runs in the parent. Every child serves its metrics on a unix
+// socket and the parent gathers them with its own.
+
+// metricsSocket is where the prefork child pid of the parent process serves
+// its metrics
+func (s *HttpServer) metricsSocket(parent int, pid int) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s-%d-%d.metrics.sock", s.Config().App.ServiceName, parent, pid))
+}
+
+// Gatherer return the metrics of the admin server, with prefork they are the
+// ones of the parent and of its children, labelled with their pid
+func (s *HttpServer) Gatherer() prometheus.Gatherer {
+	if !s.Config().Fiber.Prefork {
+		return prometheus.DefaultGatherer
+	}
+	return prometheus.Gatherers{prometheus.DefaultGatherer, metrics.ProcessGatherer(s.childMetricsSockets)}
+}
+
+// childMetricsSockets return the metrics sockets of the prefork children by pid
+func (s *HttpServer) childMetricsSockets() map[int]string {
+	s.childrenMu.Lock()
+	defer s.childrenMu.Unlock()
+
+	sockets := make(map[int]string, len(s.children))
+	for pid := range s.children {
+		sockets[pid] = s.metricsSocket(os.Getpid(), pid)
+	}
+	return sockets
+}
+
+// serveChildMetrics serve the metrics of this prefork child to the parent
+// process, nil when they cannot be served
+func (s *HttpServer) serveChildMetrics() net.Listener {
+	listener, err := metrics.ServeUnix(s.metricsSocket(os.Getppid(), os.Getpid()))
+	if err != nil {
+		s.Log("HttpServer", fmt.Sprintf("[pid %d] Cannot serve the metrics to the parent process: %v", os.Getpid(), err))
+		return nil
+	}
+	return listener
+}
@@ -0,0 +1,44 @@
+package http_server
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/metrics"
+)
+
+func TestPreforkMetrics(t *testing.T) {
+	captureLogs(t)
+	s := newTestServer(t, map[string]string{"FIBER_PREFORK": "true", "ADMIN_PORT": "9000"})
+
+	// This process stands for the child 4242 that serves its metrics, the
+	// child 4343 is gone
+	listener, err := metrics.ServeUnix(s.metricsSocket(os.Getpid(), 4242))
+	if err != nil {
+		t.Skipf("cannot listen on a unix socket: %v", err)
+	}
+	defer listener.Close()
+	s.children = map[int]bool{4242: true, 4343: true}
+	metrics.HttpRequests.WithLabelValues("GET", "/prefork-test", "200").Inc()
+
+	s.admin.Get("/metrics", metrics.HandlerFor(s.Gatherer()))
+	resp, err := s.admin.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	// The counters of the child are labelled with its pid, the unreachable
+	// child does not fail the scrape
+	want := `http_requests_total{method="GET",pid="4242",route="/prefork-test",status="200"} 1`
+	if resp.StatusCode != 200 || !strings.Contains(string(body), want) {
+		t.Errorf("metrics = %d %s, want the child series %s", resp.StatusCode, body, want)
+	}
+	if strings.Contains(string(body), `pid="4343"`) {
+		t.Errorf("metrics contain the series of the unreachable child")
+	}
+}
@@ -0,0 +1,40 @@
+package http_server
+
+import (
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// With prefork the parent process only supervises its children, they serve
+// the HTTP requests. The parent relays the signals that only reach it, SIGHUP
+// and SIGTERM, to them.
+
+func (s *HttpServer) isPreforkParent() bool {
+	return s.Config().Fiber.Prefork && !fiber.IsChild()
+}
+
+// trackChildren record the pid of every prefork child
+func (s *HttpServer) trackChildren() {
+	s.childrenMu.Lock()
+	s.children = map[int]bool{}
+	s.childrenMu.Unlock()
+
+	s.fiber.Hooks().OnFork(func(pid int) error {
+		s.childrenMu.Lock()
+		defer s.childrenMu.Unlock()
+		s.children[pid] = true
+		return nil
+	})
+}
+
+// signalChildren send sig to every prefork child
+func (s *HttpServer) signalChildren(sig os.Signal) {
+	s.childrenMu.Lock()
+	defer s.childrenMu.Unlock()
+	for pid := range s.children {
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Signal(sig)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package http_server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/exceptions"
+	"github.com/gofiber/fiber/v2"
+	fiberlog "github.com/gofiber/fiber/v2/log"
+)
+
+var logLevels = map[string]fiberlog.Level{
+	"trace": fiberlog.LevelTrace,
+	"debug": fiberlog.LevelDebug,
+	"info":  fiberlog.LevelInfo,
+	"warn":  fiberlog.LevelWarn,
+	"error": fiberlog.LevelError,
+}
+
+// Reloadable return a middleware built from the current config, it is rebuilt
+// and swapped in place every time the config is reloaded
+func (s *HttpServer) Reloadable(build func(config *config.Config) fiber.Handler) fiber.Handler {
+	var current atomic.Pointer[fiber.Handler]
+	handler := build(s.Config())
+	current.Store(&handler)
+
+	s.OnReload(func(config *config.Config) {
+		handler := build(config)
+		current.Store(&handler)
+	})
+
+	return func(c *fiber.Ctx) error {
+		return (*current.Load())(c)
+	}
+}
+
+// OnReload register a function called after the config has been reloaded
+func (s *HttpServer) OnReload(hook func(config *config.Config)) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.reloadHooks = append(s.reloadHooks, hook)
+}
+
+// OnRefresh register a function called on every reload, even when no setting
+// changed, to read again the files the server depends on (e.g. the public keys
+// of the bearer tokens)
+func (s *HttpServer) OnRefresh(hook func()) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.refreshHooks = append(s.refreshHooks, hook)
+}
+
+// Reload read the config sources and the TLS certificate again and apply the
+// runtime-tunable settings to the running server. Changes to settings that
+// require a restart are rejected and logged.
+func (s *HttpServer) Reload() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	// The certificate files and the refreshed dependencies are read again even
+	// when the config did not change
+	s.reloadCertificates()
+	for _, hook := range s.refreshHooks {
+		hook()
+	}
+
+	loaded, err := config.LoadConfig()
+	if err != nil {
+		s.Log("HttpServer", fmt.Sprintf("Config reload failed, keeping current settings: %v", err))
+		return err
+	}
+
+	var applied, rejected []string
+	current := s.Config()
+	for _, change := range config.Diff(current, loaded) {
+		if change.Reloadable {
+			applied = append(applied, change.String())
+		} else {
+			rejected = append(rejected, change.Key)
+		}
+	}
+
+	pid := os.Getpid()
+	if len(rejected) > 0 {
+		s.Log("HttpServer", fmt.Sprintf("(%d) Config reload rejected changes to %s, these settings require a restart", pid, strings.Join(rejected, ", ")))
+	}
+	if len(applied) == 0 {
+		s.Log("HttpServer", fmt.Sprintf("(%d) Config reloaded, no runtime-tunable setting changed", pid))
+		return nil
+	}
+
+	// The readers of the current config are not disturbed, they get the new
+	// one on their next s.Config()
+	next := current.Merge(loaded)
+	s.config.Store(next)
+	s.applyRuntimeConfig()
+	for _, hook := range s.reloadHooks {
+		hook(next)
+	}
+
+	s.Log("HttpServer", fmt.Sprintf("(%d) Config reloaded: %s", pid, strings.Join(applied, ", ")))
+	return nil
+}
+
+// applyRuntimeConfig push the runtime-tunable settings to the dependencies
+func (s *HttpServer) applyRuntimeConfig() {
+	config := s.Config()
+	fiberlog.SetLevel(logLevels[config.App.LogLevel])
+	exceptions.SetTracesSampleRate(config.Sentry.SentryTracesSampleRate)
+	if s.Cacher != nil {
+		s.Cacher.SetExpired(time.Minute * time.Duration(config.Redis.RedisCacheDuration))
+	}
+}
+
+// watchConfig reload the config when the config file content changes, this
+// function will block thread until stop is closed
+func (s *HttpServer) watchConfig(stop chan bool) {
+	path, interval := s.Config().File, s.Config().App.ConfigWatchInterval
+	if path == "" || interval <= 0 {
+		return
+	}
+
+	checksum := func() []byte {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		sum := sha256.Sum256(content)
+		return sum[:]
+	}
+
+	last := checksum()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			current := checksum()
+			if current == nil || bytes.Equal(current, last) {
+				continue
+			}
+			last = current
+			s.Log("HttpServer", fmt.Sprintf("Config file %s changed, reloading...", path))
+			s.Reload()
+		}
+	}
+}
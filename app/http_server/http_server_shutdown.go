@@ -0,0 +1,209 @@
+package http_server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Extra time the prefork parent gives its children to close their own
+// dependencies after their drain deadline
+const preforkCleanupGrace = 5 * time.Second
+
+// shutdown stop the services in phases so that no request is dropped
+//  1. readiness: /health, /readyz and the gRPC health service start failing
+//  2. pre-stop delay: load balancers notice and stop routing new requests
+//  3. drain: listeners are closed, in-flight requests have SHUTDOWN_TIMEOUT to complete,
+//     the admin listener is closed last so the probes and metrics stay available
+//  4. teardown: dependencies are closed in the reverse order of their initialization
+func (s *HttpServer) shutdown(httpRunning bool, grpcRunning bool, adminRunning bool) {
+	preStopDelay := s.Config().Shutdown.ShutdownPreStopDelay
+	timeout := s.Config().Shutdown.ShutdownTimeout
+
+	// Phase 1: readiness
+	s.draining.Store(true)
+	s.readiness.Reset()
+	if s.grpcHealth != nil {
+		s.grpcHealth.Shutdown()
+	}
+	// Children are usually signaled together with the parent (process group),
+	// forward the signal in case only the parent got it
+	s.signalChildren(syscall.SIGTERM)
+	s.logShutdown(1, "readiness set to failing")
+
+	// Phase 2: pre-stop delay
+	if preStopDelay > 0 {
+		s.logShutdown(2, fmt.Sprintf("waiting %s pre-stop delay", preStopDelay))
+		time.Sleep(preStopDelay)
+	} else {
+		s.logShutdown(2, "no pre-stop delay")
+	}
+
+	// Phase 3: drain
+	if s.isPreforkParent() {
+		timeout += preforkCleanupGrace
+	}
+	s.logShutdown(3, fmt.Sprintf("closing listeners, waiting up to %s for in-flight requests", timeout))
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	var wg sync.WaitGroup
+	if httpRunning {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.stopHTTP(ctx); err != nil {
+				s.Log("HttpServer", fmt.Sprintf("[pid %d] Drain incomplete: %v", os.Getpid(), err))
+			}
+		}()
+	}
+	if grpcRunning {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.stopGRPC(ctx); err != nil {
+				s.Log("GrpcServer", fmt.Sprintf("Drain incomplete, remaining calls closed: %v", err))
+			}
+		}()
+	}
+	wg.Wait()
+	if adminRunning {
+		if err := s.stopAdmin(ctx); err != nil {
+			s.Log("AdminServer", fmt.Sprintf("Drain incomplete: %v", err))
+		}
+	}
+	s.logShutdown(3, fmt.Sprintf("drained in %s", time.Since(started).Round(time.Millisecond)))
+
+	// Phase 4: teardown
+	s.logShutdown(4, "closing dependencies")
+	s.Cleanup()
+
+	if fiber.IsChild() {
+		s.reportDrained(preStopDelay + timeout + preforkCleanupGrace)
+	}
+}
+
+func (s *HttpServer) logShutdown(phase int, message string) {
+	if fiber.IsChild() {
+		return
+	}
+	s.Log("HttpServer", fmt.Sprintf("Shutdown %d/4: %s", phase, message))
+}
+
+// Prefork ----------------------------------------------------------------------------
+//
+// The prefork parent returns from Listen as soon as one child exits and then
+// kills the other children, and children exit when their parent is gone. So
+// every child drains, reports it to the parent through a unix socket, then
+// waits for the parent to exit. The parent exits once every child reported.
+
+// drainSocket is where the children of the parent process report they drained
+func (s *HttpServer) drainSocket(parent int) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s-%d.drain.sock", s.Config().App.ServiceName, parent))
+}
+
+// watchDrains listen for the drain reports of the prefork children
+func (s *HttpServer) watchDrains() {
+	s.drained = make(chan int, runtime.GOMAXPROCS(0))
+
+	path := s.drainSocket(os.Getpid())
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		s.Log("HttpServer", fmt.Sprintf("Cannot listen for prefork drain reports, children will be waited for until the deadline: %v", err))
+		return
+	}
+	s.drainListener = listener
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var pid int
+				if _, err := fmt.Fscan(conn, &pid); err == nil {
+					s.drained <- pid
+				}
+			}()
+		}
+	}()
+}
+
+// waitChildren wait until every prefork child drained or exited
+func (s *HttpServer) waitChildren(ctx context.Context) error {
+	s.childrenMu.Lock()
+	remaining := make(map[int]bool, len(s.children))
+	for pid := range s.children {
+		remaining[pid] = true
+	}
+	s.childrenMu.Unlock()
+
+	s.Log("HttpServer", fmt.Sprintf("Waiting for %d prefork children to drain", len(remaining)))
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for len(remaining) > 0 {
+		select {
+		case pid := <-s.drained:
+			delete(remaining, pid)
+		case <-ticker.C:
+			// Crashed children never report
+			for pid := range remaining {
+				if !processAlive(pid) {
+					delete(remaining, pid)
+				}
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("%d prefork children still draining: %w", len(remaining), ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+// reportDrained tell the parent this child drained, then wait up to timeout for
+// the parent to exit. Exiting first would make the parent kill the children
+// that are still draining.
+func (s *HttpServer) reportDrained(timeout time.Duration) {
+	parent := os.Getppid()
+	if err := s.sendDrainReport(parent); err != nil {
+		s.Log("HttpServer", fmt.Sprintf("[pid %d] Cannot report drain to the parent process: %v", os.Getpid(), err))
+		return
+	}
+
+	deadline := time.Now().Add(timeout)
+	for os.Getppid() == parent && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// sendDrainReport write the pid of this process to the drain socket of parent
+func (s *HttpServer) sendDrainReport(parent int) error {
+	conn, err := net.DialTimeout("unix", s.drainSocket(parent), time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = fmt.Fprintln(conn, os.Getpid())
+	return err
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,232 @@
+package http_server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTestServer return a server whose config is the required values with the
+// overrides
+func newTestServer(t *testing.T, overrides map[string]string) *HttpServer {
+	t.Helper()
+
+	values := map[string]string{
+		"HTTP_PORT":     "8000",
+		"APP_NAME":      "Stream - User Service",
+		"SERVICE_NAME":  fmt.Sprintf("shutdown-test-%d", time.Now().UnixNano()),
+		"REDIS_HOST":    "localhost",
+		"DATABASE_HOST": "localhost",
+		"DATABASE_NAME": "test",
+		"DATABASE_USER": "test",
+	}
+	for env, value := range overrides {
+		values[env] = value
+	}
+	cfg, err := config.LoadLayers(config.MapLayer(config.SourceEnv, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHttpServer(cfg, nil, nil, nil, nil)
+}
+
+// serve start the HTTP server on a free port and return its base URL
+func serve(t *testing.T, s *HttpServer) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.fiber.Listener(ln)
+	return "http://" + ln.Addr().String()
+}
+
+// logs capture the server logs of the test
+type logs struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func captureLogs(t *testing.T) *logs {
+	l := &logs{}
+	log.SetOutput(l)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return l
+}
+
+func (l *logs) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+func (l *logs) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestShutdownPhases(t *testing.T) {
+	logs := captureLogs(t)
+	s := newTestServer(t, map[string]string{"SHUTDOWN_PRE_STOP_DELAY": "300ms", "SHUTDOWN_TIMEOUT": "5s"})
+
+	started := make(chan bool)
+	s.GET("/slow", func(c *fiber.Ctx) error {
+		close(started)
+		time.Sleep(500 * time.Millisecond)
+		return c.SendString("done")
+	})
+	s.GET("/fast", func(c *fiber.Ctx) error {
+		return c.SendString("done")
+	})
+	url := serve(t, s)
+
+	// An in-flight request
+	inFlight := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != fiber.StatusOK {
+				err = fmt.Errorf("status %d", resp.StatusCode)
+			}
+		}
+		inFlight <- err
+	}()
+	<-started
+
+	done := make(chan bool)
+	go func() {
+		s.shutdown(true, false, false)
+		close(done)
+	}()
+
+	// Readiness fails at once while the listener still accepts requests for
+	// the pre-stop delay
+	time.Sleep(100 * time.Millisecond)
+	if !s.Draining() {
+		t.Error("Draining() = false during the pre-stop delay")
+	}
+	if report := s.Readiness().Run(context.Background()); report.Status != "failing" {
+		t.Errorf("readiness = %s during the pre-stop delay, want failing", report.Status)
+	}
+	resp, err := http.Get(url + "/fast")
+	if err != nil {
+		t.Fatalf("request during the pre-stop delay: %v", err)
+	}
+	resp.Body.Close()
+
+	<-done
+	if err := <-inFlight; err != nil {
+		t.Errorf("in-flight request: %v, want it drained", err)
+	}
+
+	// The phases run in order
+	output := logs.String()
+	last := -1
+	for phase := 1; phase <= 4; phase++ {
+		i := strings.Index(output, fmt.Sprintf("Shutdown %d/4", phase))
+		if i < last {
+			t.Errorf("phase %d logged out of order:\n%s", phase, output)
+		}
+		last = i
+	}
+	if strings.Contains(output, "Drain incomplete") {
+		t.Errorf("drain reported incomplete:\n%s", output)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	logs := captureLogs(t)
+	s := newTestServer(t, map[string]string{"SHUTDOWN_TIMEOUT": "1s"})
+
+	started := make(chan bool)
+	release := make(chan bool)
+	defer close(release)
+	s.GET("/stuck", func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return nil
+	})
+	url := serve(t, s)
+
+	go func() {
+		if resp, err := http.Get(url + "/stuck"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	begin := time.Now()
+	s.shutdown(true, false, false)
+	elapsed := time.Since(begin)
+
+	if elapsed < time.Second || elapsed > 3*time.Second {
+		t.Errorf("shutdown took %s, want about SHUTDOWN_TIMEOUT (1s)", elapsed)
+	}
+	if !strings.Contains(logs.String(), "Drain incomplete") {
+		t.Errorf("the stuck request is not reported:\n%s", logs.String())
+	}
+}
+
+func TestPreforkDrainReports(t *testing.T) {
+	captureLogs(t)
+	s := newTestServer(t, map[string]string{"FIBER_PREFORK": "true"})
+	s.watchDrains()
+	defer s.drainListener.Close()
+
+	// A crashed child never reports
+	crashed := exec.Command("sleep", "60")
+	if err := crashed.Start(); err != nil {
+		t.Skipf("cannot start a child process: %v", err)
+	}
+	crashed.Process.Kill()
+	crashed.Wait()
+
+	// This process stands for the child that reports
+	s.children = map[int]bool{os.Getpid(): true, crashed.Process.Pid: true}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		if err := s.sendDrainReport(os.Getpid()); err != nil {
+			t.Errorf("drain report: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.waitChildren(ctx); err != nil {
+		t.Errorf("waitChildren() = %v, want every child drained or gone", err)
+	}
+}
+
+func TestPreforkDrainDeadline(t *testing.T) {
+	captureLogs(t)
+	s := newTestServer(t, map[string]string{"FIBER_PREFORK": "true"})
+	s.watchDrains()
+	defer s.drainListener.Close()
+
+	// A live child that does not report
+	s.children = map[int]bool{os.Getpid(): true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := s.waitChildren(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "1 prefork children still draining") {
+		t.Errorf("waitChildren() = %v, want the child still draining at the deadline", err)
+	}
+}
@@ -0,0 +1,116 @@
+package http_server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/certs"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/reuseport"
+)
+
+// newCertificates load the TLS certificate, the service refuses to boot when
+// it cannot be loaded
+func (s *HttpServer) newCertificates() {
+	reloader, err := certs.NewReloader(s.Config().TLS.TLSCertFile, s.Config().TLS.TLSKeyFile, s.Config().TLS.TLSClientCAFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.certs = reloader
+
+	if !fiber.IsChild() {
+		cert := reloader.Certificate()
+		s.Log("HttpServer", fmt.Sprintf("TLS enabled, certificate %q valid until %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339)))
+		if s.Config().TLS.TLSClientCAFile != "" {
+			s.Log("HttpServer", fmt.Sprintf("mTLS enabled, client certificate: %s", s.Config().TLS.TLSClientAuth))
+		}
+	}
+}
+
+// tlsConfig return the server TLS config, the certificate is read from the
+// reloader on every new connection
+func (s *HttpServer) tlsConfig(nextProtos ...string) *tls.Config {
+	return s.certs.TLSConfig(s.Config().TLS.ClientAuth(), s.Config().TLS.MinVersion(), nextProtos...)
+}
+
+// listenTLS serve HTTPS on addr. Fiber only accepts a static certificate so the
+// listener is created here, including in the prefork children.
+func (s *HttpServer) listenTLS(addr string) error {
+	network := s.fiber.Config().Network
+
+	if !s.Config().Fiber.Prefork {
+		ln, err := net.Listen(network, addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+		return s.fiber.Listener(tls.NewListener(ln, s.tlsConfig()))
+	}
+
+	// The prefork parent only starts the children
+	if !fiber.IsChild() {
+		return s.fiber.ListenTLSWithCertificate(addr, s.certs.KeyPair())
+	}
+
+	// Same as the Fiber prefork children: one core per child, the port is
+	// shared with SO_REUSEPORT and the child exits with its parent
+	runtime.GOMAXPROCS(1)
+	ln, err := reuseport.Listen(network, addr)
+	if err != nil {
+		return fmt.Errorf("prefork: %w", err)
+	}
+	go func() {
+		for range time.NewTicker(500 * time.Millisecond).C {
+			if os.Getppid() == 1 {
+				os.Exit(1)
+			}
+		}
+	}()
+
+	// Handler build the route tree before serving
+	s.fiber.Handler()
+	return s.fiber.Server().Serve(tls.NewListener(ln, s.tlsConfig()))
+}
+
+// reloadCertificates read the certificate files again, the current certificate
+// is kept when the new files are invalid
+func (s *HttpServer) reloadCertificates() {
+	if s.certs == nil {
+		return
+	}
+
+	pid := os.Getpid()
+	changed, err := s.certs.Reload()
+	if err != nil {
+		s.Log("HttpServer", fmt.Sprintf("(%d) TLS certificate reload failed, keeping the current certificate: %v", pid, err))
+		return
+	}
+	if changed {
+		cert := s.certs.Certificate()
+		s.Log("HttpServer", fmt.Sprintf("(%d) TLS certificate reloaded, %q valid until %s", pid, cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339)))
+	}
+}
+
+// watchCertificates reload the certificate when the files change, this
+// function will block thread until stop is closed
+func (s *HttpServer) watchCertificates(stop chan bool) {
+	interval := s.Config().TLS.TLSReloadInterval
+	if s.certs == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.reloadCertificates()
+		}
+	}
+}
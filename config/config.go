@@ -1,40 +1,185 @@
 package config
 
 import (
-	"fmt"
+	"errors"
+	"flag"
+	"log"
 	"os"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/joho/godotenv"
 )
 
-// OAuth config
-var OAuthConfig *certificateConfig
+// Environment variables set before the .env file was loaded, they take
+// precedence over the .env file
+var processEnv map[string]bool
 
 type Config struct {
-	App           *appConfig
-	Fiber         *fiberConfig
-	Database      *databaseConfig
-	Redis         *redisConfig
-	Sentry        *sentryConfig
-	OpenTelemetry *openTelemetryConfig
+	App           *appConfig           `section:"app"`
+	Fiber         *fiberConfig         `section:"fiber"`
+	Database      *databaseConfig      `section:"database"`
+	Redis         *redisConfig         `section:"redis"`
+	Sentry        *sentryConfig        `section:"sentry"`
+	OpenTelemetry *openTelemetryConfig `section:"otel"`
+	OAuth         *oauthConfig         `section:"oauth"`
+	Shutdown      *shutdownConfig      `section:"shutdown"`
+	TLS           *tlsConfig           `section:"tls"`
+	Admin         *adminConfig         `section:"admin"`
+	Health        *healthConfig        `section:"health"`
+	RateLimit     *rateLimitConfig     `section:"rate_limit"`
+	Request       *requestConfig       `section:"request"`
+	Network       *networkConfig       `section:"network"`
+	OpenAPI       *openAPIConfig       `section:"openapi"`
+
+	// Sources keep where each value came from, keyed by environment variable name
+	Sources map[string]Source
+	// File is the config file path, empty when no config file is used
+	File string
+	// Profile is the environment profile selected by ENV
+	Profile *Profile `section:"-"`
 }
 
 type appConfig struct {
-	Env         string
-	HttpPort    string
-	GrpcPort    string
-	AppName     string
-	ServiceName string
+	Env         string `env:"ENV" default:"local" enum:"local,staging,production"`
+	HttpPort    int    `env:"HTTP_PORT" required:"true" min:"1" max:"65535"`
+	GrpcPort    int    `env:"GRPC_PORT" default:"8001" min:"1" max:"65535"`
+	GrpcEnabled bool   `env:"GRPC_ENABLED" default:"false"`
+	// Expose the gRPC server reflection service (grpcurl, grpcui)
+	GrpcReflection bool   `env:"GRPC_REFLECTION" default:"true"`
+	AppName        string `env:"APP_NAME" required:"true"`
+	ServiceName    string `env:"SERVICE_NAME" required:"true"`
+	LogLevel       string `env:"LOG_LEVEL" default:"info" enum:"trace,debug,info,warn,error" reload:"true"`
+	// Interval between config file change checks, 0 disables watching
+	ConfigWatchInterval time.Duration `env:"CONFIG_WATCH_INTERVAL" default:"5s" min:"0s"`
+	// Default time a component (database, cache, ...) has to start or stop
+	ComponentStartTimeout time.Duration `env:"COMPONENT_START_TIMEOUT" default:"30s" min:"1s"`
+	ComponentStopTimeout  time.Duration `env:"COMPONENT_STOP_TIMEOUT" default:"5s" min:"1s"`
+}
+
+type shutdownConfig struct {
+	// Time between readiness turning to failing and the listeners closing, it
+	// lets load balancers stop routing new requests to the instance
+	ShutdownPreStopDelay time.Duration `env:"SHUTDOWN_PRE_STOP_DELAY" default:"0s" min:"0s"`
+	// Deadline for in-flight requests to complete once the listeners are closed
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"10s" min:"1s"`
+}
+
+type rateLimitConfig struct {
+	// Length of the sliding window the limits apply to
+	RateLimitWindow time.Duration `env:"RATE_LIMIT_WINDOW" default:"1m" min:"1s" reload:"true"`
+	// Requests per window of the authenticated principals, "<tier>:<limit>,...".
+	// Anonymous clients are limited per IP by RATE_LIMIT.
+	RateLimitTiers []string `env:"RATE_LIMIT_TIERS" default:"standard:600,premium:6000" min:"1" reload:"true"`
+	// Tier of the principals without tier claim or with an unknown tier
+	RateLimitDefaultTier string `env:"RATE_LIMIT_DEFAULT_TIER" default:"standard" reload:"true"`
+	// JWT claim holding the tier name
+	RateLimitTierClaim string `env:"RATE_LIMIT_TIER_CLAIM" default:"tier" reload:"true"`
+	// API keys accepted in the X-API-Key header and their tier, "<key>:<tier>,..."
+	RateLimitAPIKeys Secret `env:"RATE_LIMIT_API_KEYS"`
+	// Cost of the matching requests, the others cost 1,
+	// "<METHOD> <path>[?<query param>]:<cost>,..."
+	RateLimitCosts []string `env:"RATE_LIMIT_COSTS" default:"GET /users?search:5" reload:"true"`
+	// Key of the HMAC signed X-RateLimit-Bypass header used by load tests, empty
+	// disables it. Not allowed in production.
+	RateLimitBypassSecret Secret `env:"RATE_LIMIT_BYPASS_SECRET"`
+	// Longest lifetime accepted for a signed bypass header
+	RateLimitBypassMaxTTL time.Duration `env:"RATE_LIMIT_BYPASS_MAX_TTL" default:"24h" min:"1m"`
+	// Client networks that are not rate limited, e.g. the load test runners.
+	// Not allowed in production.
+	RateLimitBypassCIDRs []string `env:"RATE_LIMIT_BYPASS_CIDRS"`
+}
+
+type requestConfig struct {
+	// Deadline of the context handed to the handlers, the database queries are
+	// cancelled when it expires. 0 disables it.
+	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" default:"10s" min:"0s" reload:"true"`
+	// Deadline of the matching requests, "<METHOD> <path>[?<query param>]:<timeout>,..."
+	RequestTimeoutRoutes []string `env:"REQUEST_TIMEOUT_ROUTES" reload:"true"`
+}
+
+type healthConfig struct {
+	// How long a probe report is served before the dependencies are checked
+	// again, it protects them from aggressive polling
+	HealthCacheTTL time.Duration `env:"HEALTH_CACHE_TTL" default:"1s" min:"0s"`
+	// Deadline of every dependency check
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"2s" min:"100ms"`
+}
+
+type tlsConfig struct {
+	// HTTPS is served when a certificate and key are set, they are reloaded
+	// from disk when they change
+	TLSCertFile string `env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `env:"TLS_KEY_FILE"`
+	// CA bundle to verify client certificates against (mTLS)
+	TLSClientCAFile   string        `env:"TLS_CLIENT_CA_FILE"`
+	TLSClientAuth     string        `env:"TLS_CLIENT_AUTH" default:"require" enum:"optional,require"`
+	TLSMinVersion     string        `env:"TLS_MIN_VERSION" default:"1.2" enum:"1.2,1.3"`
+	TLSReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" default:"1m" min:"0s"`
+}
+
+type adminConfig struct {
+	// Internal listener for the monitor, health probes, metrics, pprof and admin
+	// tools, 0 disables it
+	AdminPort int `env:"ADMIN_PORT" default:"0" min:"0" max:"65535"`
+	// Interface to listen on, e.g. 127.0.0.1 to only accept local connections
+	AdminHost string `env:"ADMIN_HOST"`
+	// Bearer token required by the admin routes, the health probes excepted
+	AdminToken        Secret `env:"ADMIN_TOKEN"`
+	AdminPprofEnabled bool   `env:"ADMIN_PPROF_ENABLED" default:"true"`
+	// Client networks allowed on the admin server, every client when empty. The
+	// health probes are always allowed.
+	AdminIPAllow []string `env:"ADMIN_IP_ALLOW" reload:"true"`
+	// Client networks refused on the admin server
+	AdminIPDeny []string `env:"ADMIN_IP_DENY" reload:"true"`
+}
+
+type networkConfig struct {
+	// Proxies allowed to report the client IP, CIDRs or IPs. PROXY_HEADER is
+	// ignored when the request does not come from one of them.
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	// Header carrying the client IP chain set by the trusted proxies
+	ProxyHeader string `env:"PROXY_HEADER" default:"X-Forwarded-For" enum:"Forwarded,X-Forwarded-For,X-Real-IP"`
+	// Client networks allowed on the public server, every client when empty
+	IPAllow []string `env:"IP_ALLOW" reload:"true"`
+	// Client networks refused on the public server
+	IPDeny []string `env:"IP_DENY" reload:"true"`
+	// Client networks allowed under a path of the public server, on top of
+	// IP_ALLOW, "<path>=<IP or CIDR> ...,..."
+	IPAllowGroups []string `env:"IP_ALLOW_GROUPS" reload:"true"`
+	// Client networks refused under a path of the public server, "<path>=<IP or CIDR> ...,..."
+	IPDenyGroups []string `env:"IP_DENY_GROUPS" reload:"true"`
+}
+
+type openAPIConfig struct {
+	// Serve the OpenAPI document at /openapi.json and Swagger UI at /docs
+	OpenAPIEnabled bool `env:"OPENAPI_ENABLED" default:"true"`
+	// Serve Swagger UI at /docs, disabled by the production profile
+	OpenAPISwaggerUIEnabled bool `env:"OPENAPI_SWAGGER_UI_ENABLED" default:"true"`
+	// Base URL of the swagger-ui-dist assets loaded by the Swagger UI page
+	OpenAPISwaggerUIAssets string `env:"OPENAPI_SWAGGER_UI_ASSETS" default:"https://unpkg.com/swagger-ui-dist@5.17.14"`
+	// Directory of a swagger-ui-dist copy served at /docs/assets, it replaces
+	// OPENAPI_SWAGGER_UI_ASSETS so the browsers do not load a CDN
+	OpenAPISwaggerUIDir string `env:"OPENAPI_SWAGGER_UI_DIR"`
+	// Reject the requests that do not match the document with a 400 listing
+	// the violations
+	OpenAPIValidateRequests bool `env:"OPENAPI_VALIDATE_REQUESTS" default:"true" reload:"true"`
+	// Check the responses against the document and answer a 500 listing the
+	// violations when they drift from it, for the tests
+	OpenAPIValidateResponses bool `env:"OPENAPI_VALIDATE_RESPONSES" default:"false" reload:"true"`
 }
 
 type fiberConfig struct {
+	Prefork          bool   `env:"FIBER_PREFORK" default:"false"`
+	CorsAllowOrigins string `env:"CORS_ALLOW_ORIGINS" default:"*" reload:"true"`
+	RateLimit        int    `env:"RATE_LIMIT" default:"60" min:"1" reload:"true"`
+	MonitorEnabled   bool   `env:"MONITOR_ENABLED" default:"true"`
+
 	Config     fiber.Config
 	Middleware *fiberMiddlewareConfig
 }
@@ -44,139 +189,196 @@ type fiberMiddlewareConfig struct {
 	Cors    cors.Config
 	Logger  logger.Config
 	Favicon favicon.Config
-	Limiter limiter.Config
 }
 
 type databaseConfig struct {
-	DatabaseDSN string
+	// Full connection URL, an alternative to the separate connection variables
+	DatabaseURL      Secret `env:"DATABASE_URL"`
+	DatabaseHost     string `env:"DATABASE_HOST"`
+	DatabasePort     int    `env:"DATABASE_PORT" default:"5432" min:"1" max:"65535"`
+	DatabaseName     string `env:"DATABASE_NAME"`
+	DatabaseUser     string `env:"DATABASE_USER"`
+	DatabasePassword Secret `env:"DATABASE_PASSWORD"`
+	DatabaseTimezone string `env:"DATABASE_TIMEZONE" default:"UTC"`
+	DatabaseDSN      Secret
+	// Connection options
+	DatabaseSSLMode          string        `env:"DATABASE_SSLMODE" default:"disable" enum:"disable,allow,prefer,require,verify-ca,verify-full"`
+	DatabaseSSLRootCert      string        `env:"DATABASE_SSLROOTCERT"`
+	DatabaseSSLCert          string        `env:"DATABASE_SSLCERT"`
+	DatabaseSSLKey           string        `env:"DATABASE_SSLKEY"`
+	DatabaseApplicationName  string        `env:"DATABASE_APPLICATION_NAME"`
+	DatabaseConnectTimeout   time.Duration `env:"DATABASE_CONNECT_TIMEOUT" default:"10s" min:"0s"`
+	DatabaseStatementTimeout time.Duration `env:"DATABASE_STATEMENT_TIMEOUT" default:"0s" min:"0s"`
+	DatabaseSearchPath       string        `env:"DATABASE_SEARCH_PATH"`
 	// Additional
-	DatabaseMaxIdleConns int
-	DatabaseMaxOpenConns int
+	DatabaseMaxIdleConns    int           `env:"DATABASE_MAX_IDLE_CONNS" default:"2" min:"0"`
+	DatabaseMaxOpenConns    int           `env:"DATABASE_MAX_OPEN_CONNS" default:"3" min:"1"`
+	DatabaseConnMaxIdleTime time.Duration `env:"DATABASE_CONN_MAX_IDLE_TIME" default:"1h" min:"0s"`
+	DatabaseConnMaxLifetime time.Duration `env:"DATABASE_CONN_MAX_LIFETIME" default:"24h" min:"0s"`
 }
 
 type redisConfig struct {
-	RedisHost          string
-	RedisPort          string
-	RedisUsername      string
-	RedisPassword      string
-	RedisCachePrefix   string
-	RedisCacheDuration int
+	RedisHost          string `env:"REDIS_HOST" required:"true"`
+	RedisPort          int    `env:"REDIS_PORT" default:"6379" min:"1" max:"65535"`
+	RedisUsername      string `env:"REDIS_USERNAME"`
+	RedisPassword      Secret `env:"REDIS_PASSWORD"`
+	RedisCachePrefix   string `env:"REDIS_CACHE_PREFIX"`
+	RedisCacheDuration int    `env:"REDIS_CACHE_DURATION" default:"5" min:"1" reload:"true"`
 }
 
 type sentryConfig struct {
-	SentryDSN              string
-	SentryEnableTracing    bool
-	SentryTracesSampleRate float64
+	SentryDSN              Secret  `env:"SENTRY_DSN"`
+	SentryEnableTracing    bool    `env:"SENTRY_ENABLE_TRACING" default:"false"`
+	SentryTracesSampleRate float64 `env:"SENTRY_TRACES_SAMPLE_RATE" default:"0.2" min:"0" max:"1" reload:"true"`
 }
 
 type openTelemetryConfig struct {
-	OtelExporterOTLPEndpoint string
-	OtelInsecureMode         bool
+	OtelExporterOTLPEndpoint string `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OtelInsecureMode         bool   `env:"OTEL_INSECURE_MODE" default:"true"`
+}
+
+type oauthConfig struct {
+	// Require a bearer token on the REST routes and enforce their access
+	// policies, it needs a public key
+	Enabled bool `env:"OAUTH_ENABLED" default:"false"`
+	// PEM certificate or public key, a bare base64 body is read as a certificate
+	PublicKey string `env:"OAUTH_PUBLIC_KEY"`
+	// PEM files with additional certificates or public keys (e.g. during key rotation)
+	PublicKeyPaths []string      `env:"OAUTH_PUBLIC_KEY_PATHS"`
+	PrivateKey     Secret        `env:"OAUTH_PRIVATE_KEY"`
+	Issuer         string        `env:"OAUTH_ISSUER"`
+	Audiences      []string      `env:"OAUTH_AUDIENCES"`
+	Algorithms     []string      `env:"OAUTH_ALGORITHMS" default:"RS256" enum:"RS256,RS384,RS512,PS256,PS384,PS512,ES256,ES384,ES512,EdDSA" min:"1"`
+	Leeway         time.Duration `env:"OAUTH_LEEWAY" default:"0s" min:"0s"`
+	// Claim granting the scopes, a space separated string or a list
+	ScopeClaim string `env:"OAUTH_SCOPE_CLAIM" default:"scope"`
+	// Claim holding the roles, a list or a space separated string. Nested
+	// claims are dotted, e.g. realm_access.roles.
+	RolesClaim string `env:"OAUTH_ROLES_CLAIM" default:"roles"`
+	// Time the user of a token subject is cached, 0 loads it on every request
+	UserCacheTTL time.Duration `env:"OAUTH_USER_CACHE_TTL" default:"30s" min:"0s"`
+	// Time the public keys are cached before they are read again, e.g. from
+	// the rotated OAUTH_PUBLIC_KEY_PATHS files, 0 reads them on every request
+	KeysTTL time.Duration `env:"OAUTH_KEYS_TTL" default:"5m" min:"0s"`
 }
 
-type certificateConfig struct {
-	PublicKey  string
-	PrivateKey string
+// NewConfig load and validate the configuration, the service refuses to boot
+// when any value is missing or invalid
+func NewConfig(base ...Layer) *Config {
+	config, err := LoadConfig(base...)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !fiber.IsChild() {
+		counts := map[Source]int{}
+		for _, source := range config.Sources {
+			counts[source]++
+		}
+		log.Printf("Config loaded: %d from flags, %d from env, %d from file, %d from profile, %d defaults",
+			counts[SourceFlag], counts[SourceEnv], counts[SourceFile], counts[SourceProfile], counts[SourceDefault])
+		log.Println(config.Profile.Summary())
+	}
+
+	return config
 }
 
-func NewConfig() *Config {
+// LoadConfig load the configuration, sources are merged in this order (the last
+// one wins): struct tag defaults, base layers, config file, environment
+// variables (including .env file) and command-line flags
+func LoadConfig(base ...Layer) (*Config, error) {
 	// Load .env file
-	godotenv.Load()
+	loadDotEnv()
 
-	// Set secrets
-	OAuthConfig = &certificateConfig{
-		PublicKey:  os.Getenv("OAUTH_PUBLIC_KEY"),
-		PrivateKey: os.Getenv("OAUTH_PRIVATE_KEY"),
+	flags, configFile, err := FlagLayer(&Config{}, os.Args[1:])
+	if err != nil {
+		return nil, err
 	}
 
-	return &Config{
-		App: &appConfig{
-			Env:         os.Getenv("ENV"),
-			HttpPort:    os.Getenv("HTTP_PORT"),
-			GrpcPort:    os.Getenv("GRPC_PORT"),
-			AppName:     os.Getenv("APP_NAME"),
-			ServiceName: os.Getenv("SERVICE_NAME"),
-		},
-		Fiber: NewFiberConfig(),
-		Database: &databaseConfig{
-			DatabaseDSN: func() string {
-				return fmt.Sprintf(
-					`host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=%s`,
-					os.Getenv("DATABASE_HOST"),
-					os.Getenv("DATABASE_USER"),
-					os.Getenv("DATABASE_PASSWORD"),
-					os.Getenv("DATABASE_NAME"),
-					os.Getenv("DATABASE_PORT"),
-					os.Getenv("DATABASE_TIMEZONE"),
-				)
-			}(),
-			DatabaseMaxIdleConns: func() int {
-				// Default max idle conns is 2
-				databaseMaxIdleConns := 2
-				envDatabaseMaxIdleConns, err := strconv.Atoi(os.Getenv("DATABASE_MAX_IDLE_CONNS"))
-				if err == nil {
-					databaseMaxIdleConns = envDatabaseMaxIdleConns
-				}
-				return databaseMaxIdleConns
-			}(),
-			DatabaseMaxOpenConns: func() int {
-				// Default max open conns is 3
-				databaseMaxOpenConns := 3
-				envDatabaseMaxOpenConns, err := strconv.Atoi(os.Getenv("DATABASE_MAX_OPEN_CONNS"))
-				if err == nil {
-					databaseMaxOpenConns = envDatabaseMaxOpenConns
-				}
-				return databaseMaxOpenConns
-			}(),
-		},
-		Redis: &redisConfig{
-			RedisHost:        os.Getenv("REDIS_HOST"),
-			RedisPort:        os.Getenv("REDIS_PORT"),
-			RedisUsername:    os.Getenv("REDIS_USERNAME"),
-			RedisPassword:    os.Getenv("REDIS_PASSWORD"),
-			RedisCachePrefix: os.Getenv("REDIS_CACHE_PREFIX"),
-			RedisCacheDuration: func() int {
-				// Default max cache duration is 5
-				redisCacheDuration := 5
-				envRedisCacheDuration, err := strconv.Atoi(os.Getenv("REDIS_CACHE_DURATION"))
-				if err == nil {
-					redisCacheDuration = envRedisCacheDuration
-				}
-				return redisCacheDuration
-			}(),
-		},
-		Sentry: &sentryConfig{
-			SentryDSN: os.Getenv("SENTRY_DSN"),
-			SentryEnableTracing: func() bool {
-				// Default is false
-				sentryEnableTracing := false
-				envSentryEnableTracing, err := strconv.ParseBool(os.Getenv("SENTRY_ENABLE_TRACING"))
-				if err == nil {
-					sentryEnableTracing = envSentryEnableTracing
-				}
-				return sentryEnableTracing
-			}(),
-			SentryTracesSampleRate: func() float64 {
-				// Default traces sample rate is 0.2
-				sentryTracesSampleRate := 0.2
-				envSentryTracesSampleRate, err := strconv.ParseFloat(os.Getenv("SENTRY_TRACES_SAMPLE_RATE"), 64)
-				if err == nil {
-					sentryTracesSampleRate = envSentryTracesSampleRate
-				}
-				return sentryTracesSampleRate
-			}(),
-		},
-		OpenTelemetry: &openTelemetryConfig{
-			OtelExporterOTLPEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
-			OtelInsecureMode: func() bool {
-				// Default insecure mode is true
-				otelInsecureMode := true
-				envOtelInsecureMode, err := strconv.ParseBool(os.Getenv("OTEL_INSECURE_MODE"))
-				if err == nil {
-					otelInsecureMode = envOtelInsecureMode
-				}
-				return otelInsecureMode
-			}(),
-		},
+	layers := append([]Layer{}, base...)
+	if configFile == "" {
+		configFile = os.Getenv("CONFIG_FILE")
+	}
+	if configFile != "" {
+		file, err := FileLayer(configFile)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, file)
+	}
+	layers = append(layers, EnvLayer(), flags)
+
+	config, err := LoadLayers(layers...)
+	if err != nil {
+		return nil, err
+	}
+	config.File = configFile
+
+	return config, nil
+}
+
+// LoadLayers load the configuration from the given layers only, on top of the
+// struct tag defaults and the profile selected by ENV
+func LoadLayers(layers ...Layer) (*Config, error) {
+	config := &Config{}
+
+	// Select the profile first, its defaults are the lowest layer
+	profileName, _, _, _ := lookup(layers, newField("app", "ENV"), false)
+	if profileName == "" {
+		profileName = "local"
+	}
+	profile, ok := Profiles[profileName]
+	if !ok {
+		profile = Profiles["local"]
+	}
+	layers = append([]Layer{profileLayer{profile: profile}}, layers...)
+
+	var err error
+	config.Sources, err = Load(config, layers...)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Profile = profile
+	if err := profile.Check(config); err != nil {
+		return nil, err
+	}
+
+	config.Database.DatabaseDSN = Secret(config.Database.buildDSN(config.App.ServiceName))
+	NewFiberConfig(config.App, config.Network, config.Fiber)
+
+	return config, nil
+}
+
+// loadDotEnv set the .env file values as environment variables without
+// overriding the variables of the process. Unlike godotenv.Load it can be
+// called again on reload to pick up .env file changes.
+func loadDotEnv() {
+	if processEnv == nil {
+		processEnv = map[string]bool{}
+		for _, kv := range os.Environ() {
+			processEnv[strings.SplitN(kv, "=", 2)[0]] = true
+		}
+	}
+
+	dotEnv, err := godotenv.Read()
+	if err != nil {
+		return
+	}
+	for key, value := range dotEnv {
+		if !processEnv[key] {
+			os.Setenv(key, value)
+		}
+	}
+}
+
+// SourceOf return where the value of an environment variable name came from
+func (c *Config) SourceOf(env string) Source {
+	if source, ok := c.Sources[env]; ok {
+		return source
 	}
+	return SourceDefault
 }
@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+// Smallest valid configuration of the tests, keyed by environment variable name
+var requiredValues = map[string]string{
+	"HTTP_PORT":     "8000",
+	"APP_NAME":      "Stream - User Service",
+	"SERVICE_NAME":  "user-service",
+	"REDIS_HOST":    "localhost",
+	"DATABASE_HOST": "localhost",
+	"DATABASE_NAME": "test",
+	"DATABASE_USER": "test",
+}
+
+// values return the required values with the overrides, an empty override
+// removes the value
+func values(overrides map[string]string) map[string]string {
+	merged := map[string]string{}
+	for env, value := range requiredValues {
+		merged[env] = value
+	}
+	for env, value := range overrides {
+		if value == "" {
+			delete(merged, env)
+			continue
+		}
+		merged[env] = value
+	}
+	return merged
+}
+
+// mustLoad load the required values with the overrides, the test fails when
+// the config is invalid
+func mustLoad(t *testing.T, overrides map[string]string) *Config {
+	t.Helper()
+
+	config, err := LoadLayers(MapLayer(SourceEnv, values(overrides)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
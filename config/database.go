@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Validate check the settings that depend on each other
+func (d *databaseConfig) Validate() []*FieldError {
+	var errs []*FieldError
+	if d.DatabaseURL != "" {
+		u, err := url.Parse(d.DatabaseURL.Value())
+		if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			errs = append(errs, &FieldError{Key: "DATABASE_URL", Reason: "must be a postgres:// or postgresql:// URL"})
+		}
+		return errs
+	}
+
+	// Without DATABASE_URL the connection is built from the separate variables
+	for _, field := range [][2]string{
+		{"DATABASE_HOST", d.DatabaseHost},
+		{"DATABASE_NAME", d.DatabaseName},
+		{"DATABASE_USER", d.DatabaseUser},
+	} {
+		if field[1] == "" {
+			errs = append(errs, &FieldError{Key: field[0], Reason: "is required when DATABASE_URL is not set"})
+		}
+	}
+
+	return errs
+}
+
+// options return the libpq connection options other than the address and
+// credentials, runtime parameters (statement_timeout, search_path) are passed
+// through by pgx
+func (d *databaseConfig) options(applicationName string) [][2]string {
+	options := [][2]string{
+		{"sslmode", d.DatabaseSSLMode},
+		{"sslrootcert", d.DatabaseSSLRootCert},
+		{"sslcert", d.DatabaseSSLCert},
+		{"sslkey", d.DatabaseSSLKey},
+		{"TimeZone", d.DatabaseTimezone},
+		{"application_name", applicationName},
+		{"search_path", d.DatabaseSearchPath},
+	}
+	if d.DatabaseConnectTimeout > 0 {
+		options = append(options, [2]string{"connect_timeout", fmt.Sprint(int(d.DatabaseConnectTimeout.Round(time.Second).Seconds()))})
+	}
+	if d.DatabaseStatementTimeout > 0 {
+		options = append(options, [2]string{"statement_timeout", fmt.Sprint(d.DatabaseStatementTimeout.Milliseconds())})
+	}
+
+	return options
+}
+
+// buildDSN make the connection string, DATABASE_URL is used as the base when
+// it is set and its own query parameters win over the separate options
+func (d *databaseConfig) buildDSN(applicationName string) string {
+	if d.DatabaseApplicationName != "" {
+		applicationName = d.DatabaseApplicationName
+	}
+
+	if d.DatabaseURL != "" {
+		u, _ := url.Parse(d.DatabaseURL.Value())
+		query := u.Query()
+		for _, option := range d.options(applicationName) {
+			if option[1] != "" && !query.Has(option[0]) {
+				query.Set(option[0], option[1])
+			}
+		}
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	dsn := []string{
+		"host=" + quoteDSNValue(d.DatabaseHost),
+		"user=" + quoteDSNValue(d.DatabaseUser),
+		"password=" + quoteDSNValue(d.DatabasePassword.Value()),
+		"dbname=" + quoteDSNValue(d.DatabaseName),
+		fmt.Sprintf("port=%d", d.DatabasePort),
+	}
+	for _, option := range d.options(applicationName) {
+		if option[1] != "" {
+			dsn = append(dsn, option[0]+"="+quoteDSNValue(option[1]))
+		}
+	}
+
+	return strings.Join(dsn, " ")
+}
+
+// quoteDSNValue quote a keyword/value connection string value when needed
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
@@ -1,33 +1,26 @@
 package config
 
 import (
-	"os"
-	"strconv"
-
 	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
-func NewFiberConfig() *fiberConfig {
-	var isPrefork bool
-	if os.Getenv("FIBER_PREFORK") == "true" {
-		isPrefork = true
-	} else {
-		isPrefork = false
-	}
-
-	FiberConfig := fiber.Config{
-		Prefork:                 isPrefork,
+// NewFiberConfig build Fiber and middleware configs from the loaded settings
+func NewFiberConfig(app *appConfig, network *networkConfig, f *fiberConfig) *fiberConfig {
+	// The client IP is resolved from PROXY_HEADER by the ClientIP middleware,
+	// the trusted proxies are only set for c.Protocol() and c.Hostname()
+	f.Config = fiber.Config{
+		Prefork:                 f.Prefork,
 		CaseSensitive:           true,
 		StrictRouting:           true,
 		EnableTrustedProxyCheck: true,
+		TrustedProxies:          network.TrustedProxies,
 		ServerHeader:            "",
-		AppName:                 os.Getenv("APP_NAME"),
+		AppName:                 app.AppName,
 		JSONEncoder:             json.Marshal,
 		JSONDecoder:             json.Unmarshal,
 	}
@@ -37,12 +30,10 @@ func NewFiberConfig() *fiberConfig {
 	}
 
 	corsAllowCredentials := false
-	var corsAllowOrigins string
-	if os.Getenv("CORS_ALLOW_ORIGINS") != "" && os.Getenv("CORS_ALLOW_ORIGINS") != "*" {
-		corsAllowOrigins = os.Getenv("CORS_ALLOW_ORIGINS")
+	corsAllowOrigins := "*"
+	if f.CorsAllowOrigins != "*" {
+		corsAllowOrigins = f.CorsAllowOrigins
 		corsAllowCredentials = true
-	} else {
-		corsAllowOrigins = "*"
 	}
 
 	CorsConfig := cors.Config{
@@ -52,7 +43,7 @@ func NewFiberConfig() *fiberConfig {
 	}
 
 	LoggerConfig := logger.Config{
-		Format: "[${ip}]:${port} (${pid}) ${locals:requestid} ${status} - ${method} ${path}\n",
+		Format: "[${locals:clientip}]:${port} (${pid}) ${locals:requestid} ${status} - ${method} ${path}\n",
 	}
 
 	FaviconConfig := favicon.Config{
@@ -60,30 +51,12 @@ func NewFiberConfig() *fiberConfig {
 		File: "",
 	}
 
-	var rateLimit int
-	envRateLimit, err := strconv.Atoi(os.Getenv("RATE_LIMIT"))
-	if err != nil {
-		// Default cache duration is 60 seconds
-		rateLimit = 60
-	} else {
-		rateLimit = envRateLimit
+	f.Middleware = &fiberMiddlewareConfig{
+		ETag:    ETagConfig,
+		Cors:    CorsConfig,
+		Logger:  LoggerConfig,
+		Favicon: FaviconConfig,
 	}
 
-	LimiterConfig := limiter.Config{
-		Max: rateLimit,
-		Next: func(c *fiber.Ctx) bool {
-			return c.Query("loadtest") == "true"
-		},
-	}
-
-	return &fiberConfig{
-		Config: FiberConfig,
-		Middleware: &fiberMiddlewareConfig{
-			ETag:    ETagConfig,
-			Cors:    CorsConfig,
-			Logger:  LoggerConfig,
-			Favicon: FaviconConfig,
-			Limiter: LimiterConfig,
-		},
-	}
+	return f
 }
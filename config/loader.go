@@ -0,0 +1,344 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config schema struct tags
+//
+//	env:"HTTP_PORT"               environment variable name
+//	default:"8000"                value used when the variable is unset or empty
+//	required:"true"               fail when no value (and no default) is found
+//	min:"1" max:"65535"           numeric bounds, or length bounds for strings and lists
+//	enum:"local,staging"          list of accepted values
+//	reload:"true"                 can be changed on a running server (see Config.Merge)
+//
+// Sections (pointer to struct fields) are tagged with section:"database", the
+// section name is used to build config file keys (DATABASE_HOST → database.host).
+// Pointer fields tagged section:"-" are not part of the schema.
+const (
+	tagSection  = "section"
+	tagEnv      = "env"
+	tagDefault  = "default"
+	tagRequired = "required"
+	tagMin      = "min"
+	tagMax      = "max"
+	tagEnum     = "enum"
+	tagReload   = "reload"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// validator is implemented by sections with rules spanning several fields
+type validator interface {
+	Validate() []*FieldError
+}
+
+type (
+	// FieldError describe a single invalid configuration value
+	FieldError struct {
+		Key    string
+		Reason string
+	}
+	// LoadError collect every invalid configuration value found while loading
+	LoadError struct {
+		Errors []*FieldError
+	}
+)
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Reason)
+}
+
+func (e *LoadError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid configuration (%d problems):", len(e.Errors))
+	for _, err := range e.Errors {
+		sb.WriteString("\n  - ")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Load fill every tagged field of target (a pointer to struct) from the given
+// layers, then validate them. All problems are reported at once. It returns
+// the source each value came from, keyed by environment variable name.
+func Load(target interface{}, layers ...Layer) (map[string]Source, error) {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config: Load expects a pointer to struct, got %T", target)
+	}
+
+	sources := map[string]Source{}
+	known := map[string]bool{}
+	loadErr := &LoadError{}
+	walk(v.Elem(), "", func(field *Field, tag reflect.StructTag, value reflect.Value) {
+		known[field.Key] = true
+		if value.Type() == secretType {
+			known[field.fileVariant().Key] = true
+		}
+		if err := loadField(field, tag, value, layers, sources); err != nil {
+			loadErr.Errors = append(loadErr.Errors, err)
+		}
+	})
+
+	// Cross-field validation of the sections
+	for i := 0; i < v.Elem().NumField(); i++ {
+		field := v.Elem().Field(i)
+		if field.Kind() != reflect.Pointer || field.IsNil() || !v.Elem().Type().Field(i).IsExported() {
+			continue
+		}
+		if section, ok := field.Interface().(validator); ok {
+			loadErr.Errors = append(loadErr.Errors, section.Validate()...)
+		}
+	}
+
+	// Report config file keys that match no field, they are most likely typos
+	for _, layer := range layers {
+		if file, ok := layer.(*fileLayer); ok {
+			for _, key := range file.Unknown(known) {
+				loadErr.Errors = append(loadErr.Errors, &FieldError{Key: key, Reason: "unknown key in config file " + file.path})
+			}
+		}
+	}
+
+	if len(loadErr.Errors) > 0 {
+		return sources, loadErr
+	}
+
+	return sources, nil
+}
+
+// Fields list every configuration field declared by target
+func Fields(target interface{}) []*Field {
+	var fields []*Field
+	walk(reflect.ValueOf(target).Elem(), "", func(field *Field, _ reflect.StructTag, value reflect.Value) {
+		fields = append(fields, field)
+		if value.Type() == secretType {
+			fields = append(fields, field.fileVariant())
+		}
+	})
+	return fields
+}
+
+func walk(v reflect.Value, section string, fn func(field *Field, tag reflect.StructTag, value reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		value := v.Field(i)
+		if !structField.IsExported() || structField.Tag.Get(tagSection) == "-" {
+			continue
+		}
+
+		env, ok := structField.Tag.Lookup(tagEnv)
+		if !ok {
+			// Walk into nested config sections
+			if structField.Type.Kind() == reflect.Pointer && structField.Type.Elem().Kind() == reflect.Struct {
+				if value.IsNil() {
+					value.Set(reflect.New(structField.Type.Elem()))
+				}
+				nested := section
+				if name, ok := structField.Tag.Lookup(tagSection); ok {
+					nested = name
+				}
+				walk(value.Elem(), nested, fn)
+			}
+			continue
+		}
+
+		fn(newField(section, env), structField.Tag, value)
+	}
+}
+
+// lookup find the value of a field in the layer with the highest precedence
+func lookup(layers []Layer, field *Field, secret bool) (string, Source, bool, *FieldError) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		if v, ok := layers[i].Lookup(field); ok {
+			return v, layers[i].Source(), true, nil
+		}
+		// Secrets can be read from a mounted file
+		if !secret {
+			continue
+		}
+		if path, ok := layers[i].Lookup(field.fileVariant()); ok {
+			v, err := readSecretFile(path)
+			if err != nil {
+				return "", layers[i].Source(), false, &FieldError{Key: field.Env + "_FILE", Reason: fmt.Sprintf("cannot read secret file (from %s): %v", layers[i].Source(), err)}
+			}
+			return v, layers[i].Source(), true, nil
+		}
+	}
+
+	return "", SourceDefault, false, nil
+}
+
+func loadField(field *Field, tag reflect.StructTag, value reflect.Value, layers []Layer, sources map[string]Source) *FieldError {
+	secret := value.Type() == secretType
+	raw, source, ok, fieldErr := lookup(layers, field, secret)
+	if fieldErr != nil {
+		return fieldErr
+	}
+	if !ok {
+		raw = tag.Get(tagDefault)
+	}
+
+	if secret {
+		v, err := ResolveSecret(context.Background(), raw)
+		if err != nil {
+			return &FieldError{Key: field.Env, Reason: fmt.Sprintf("%v (from %s)", err, source)}
+		}
+		raw = v
+	}
+
+	if raw == "" {
+		if tag.Get(tagRequired) == "true" {
+			return &FieldError{Key: field.Env, Reason: "is required but not set"}
+		}
+		return nil
+	}
+	sources[field.Env] = source
+
+	if err := setValue(value, raw); err != nil {
+		return &FieldError{Key: field.Env, Reason: fmt.Sprintf("%s (from %s)", err, source)}
+	}
+
+	if enum, ok := tag.Lookup(tagEnum); ok {
+		if err := checkEnum(value, strings.Split(enum, ",")); err != nil {
+			return &FieldError{Key: field.Env, Reason: fmt.Sprintf("%s (from %s)", err, source)}
+		}
+	}
+
+	if err := checkBounds(value, tag.Get(tagMin), tag.Get(tagMax)); err != nil {
+		return &FieldError{Key: field.Env, Reason: fmt.Sprintf("%s (from %s)", err, source)}
+	}
+
+	return nil
+}
+
+func setValue(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid duration (e.g. 30s, 5m)", raw)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid boolean (true/false)", raw)
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid integer", raw)
+		}
+		value.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", raw)
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", value.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config type %s", value.Type())
+	}
+
+	return nil
+}
+
+func checkEnum(value reflect.Value, enum []string) error {
+	values := []string{fmt.Sprint(value.Interface())}
+	if value.Kind() == reflect.Slice {
+		values = value.Interface().([]string)
+	}
+
+	for _, v := range values {
+		found := false
+		for _, allowed := range enum {
+			if v == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%q is not one of [%s]", v, strings.Join(enum, ", "))
+		}
+	}
+
+	return nil
+}
+
+func checkBounds(value reflect.Value, min string, max string) error {
+	if min == "" && max == "" {
+		return nil
+	}
+
+	var (
+		current float64
+		unit    string
+		parse   = func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+	)
+
+	switch {
+	case value.Type() == durationType:
+		current = float64(value.Int())
+		parse = func(s string) (float64, error) {
+			d, err := time.ParseDuration(s)
+			return float64(d), err
+		}
+	case value.Kind() == reflect.Int || value.Kind() == reflect.Int64:
+		current = float64(value.Int())
+	case value.Kind() == reflect.Float64:
+		current = value.Float()
+	case value.Kind() == reflect.String || value.Kind() == reflect.Slice:
+		current = float64(value.Len())
+		unit = " in length"
+	default:
+		return nil
+	}
+
+	if min != "" {
+		bound, err := parse(min)
+		if err != nil {
+			return fmt.Errorf("invalid min tag %q", min)
+		}
+		if current < bound {
+			return fmt.Errorf("must be at least %s%s, got %v", min, unit, value.Interface())
+		}
+	}
+
+	if max != "" {
+		bound, err := parse(max)
+		if err != nil {
+			return fmt.Errorf("invalid max tag %q", max)
+		}
+		if current > bound {
+			return fmt.Errorf("must be at most %s%s, got %v", max, unit, value.Interface())
+		}
+	}
+
+	return nil
+}
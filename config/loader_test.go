@@ -0,0 +1,355 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type (
+	testConfig struct {
+		Server *testServerConfig `section:"server"`
+	}
+	testServerConfig struct {
+		Port     int           `env:"SERVER_PORT" default:"8000" min:"1" max:"65535"`
+		Name     string        `env:"SERVER_NAME" required:"true" min:"3"`
+		Mode     string        `env:"SERVER_MODE" default:"fast" enum:"fast,safe"`
+		Hosts    []string      `env:"SERVER_HOSTS" enum:"a,b,c" max:"2"`
+		Timeout  time.Duration `env:"SERVER_TIMEOUT" default:"5s" min:"1s"`
+		Ratio    float64       `env:"SERVER_RATIO" default:"0.5" min:"0" max:"1"`
+		Debug    bool          `env:"SERVER_DEBUG"`
+		Password Secret        `env:"SERVER_PASSWORD"`
+	}
+)
+
+func loadTest(values map[string]string) (*testConfig, map[string]Source, error) {
+	config := &testConfig{}
+	sources, err := Load(config, MapLayer(SourceEnv, values))
+	return config, sources, err
+}
+
+// fieldErrors return the reasons of a LoadError keyed by environment variable
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("err = %v, want a *LoadError", err)
+	}
+	reasons := map[string]string{}
+	for _, fieldErr := range loadErr.Errors {
+		reasons[fieldErr.Key] = fieldErr.Reason
+	}
+	return reasons
+}
+
+func TestLoadDefaults(t *testing.T) {
+	config, sources, err := loadTest(map[string]string{"SERVER_NAME": "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := config.Server
+	if server.Port != 8000 || server.Mode != "fast" || server.Timeout != 5*time.Second || server.Ratio != 0.5 || server.Debug {
+		t.Errorf("server = %+v, want the defaults", server)
+	}
+	if sources["SERVER_NAME"] != SourceEnv || sources["SERVER_PORT"] != SourceDefault {
+		t.Errorf("sources = %v, want SERVER_NAME from env and SERVER_PORT default", sources)
+	}
+}
+
+func TestLoadCollectsErrors(t *testing.T) {
+	_, _, err := loadTest(map[string]string{
+		"SERVER_PORT":    "http",
+		"SERVER_MODE":    "slow",
+		"SERVER_TIMEOUT": "soon",
+		"SERVER_DEBUG":   "maybe",
+	})
+
+	reasons := fieldErrors(t, err)
+	want := map[string]string{
+		"SERVER_PORT":    `"http" is not a valid integer`,
+		"SERVER_NAME":    "is required but not set",
+		"SERVER_MODE":    `"slow" is not one of [fast, safe]`,
+		"SERVER_TIMEOUT": `"soon" is not a valid duration`,
+		"SERVER_DEBUG":   `"maybe" is not a valid boolean`,
+	}
+	if len(reasons) != len(want) {
+		t.Errorf("errors = %v, want %d", reasons, len(want))
+	}
+	for key, reason := range want {
+		if !strings.Contains(reasons[key], reason) {
+			t.Errorf("%s: reason = %q, want %q", key, reasons[key], reason)
+		}
+	}
+	if !strings.Contains(err.Error(), "invalid configuration (5 problems)") {
+		t.Errorf("error = %q, want every problem counted", err)
+	}
+}
+
+func TestLoadBounds(t *testing.T) {
+	tests := []struct {
+		env    string
+		value  string
+		reason string
+	}{
+		{"SERVER_PORT", "0", "must be at least 1, got 0"},
+		{"SERVER_PORT", "70000", "must be at most 65535, got 70000"},
+		{"SERVER_PORT", "65535", ""},
+		{"SERVER_TIMEOUT", "500ms", "must be at least 1s"},
+		{"SERVER_TIMEOUT", "1s", ""},
+		{"SERVER_RATIO", "1.5", "must be at most 1"},
+		{"SERVER_RATIO", "-0.1", "must be at least 0"},
+		{"SERVER_NAME", "ab", "must be at least 3 in length"},
+		{"SERVER_HOSTS", "a,b,c", "must be at most 2 in length"},
+		{"SERVER_HOSTS", "a, b", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+			values := map[string]string{"SERVER_NAME": "api", tt.env: tt.value}
+			_, _, err := loadTest(values)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("err = %v, want none", err)
+				}
+				return
+			}
+			if reason := fieldErrors(t, err)[tt.env]; !strings.Contains(reason, tt.reason) {
+				t.Errorf("reason = %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestLoadEnum(t *testing.T) {
+	tests := []struct {
+		env    string
+		value  string
+		reason string
+	}{
+		{"SERVER_MODE", "safe", ""},
+		{"SERVER_MODE", "Safe", `"Safe" is not one of [fast, safe]`},
+		{"SERVER_HOSTS", "a,c", ""},
+		{"SERVER_HOSTS", "a,d", `"d" is not one of [a, b, c]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+			_, _, err := loadTest(map[string]string{"SERVER_NAME": "api", tt.env: tt.value})
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("err = %v, want none", err)
+				}
+				return
+			}
+			if reason := fieldErrors(t, err)[tt.env]; !strings.Contains(reason, tt.reason) {
+				t.Errorf("reason = %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSourcePrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("app:\n  log_level: warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := FileLayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags, _, err := FlagLayer(&Config{}, []string{"--log-level", "trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := MapLayer(SourceEnv, values(map[string]string{"LOG_LEVEL": "error"}))
+
+	tests := []struct {
+		name       string
+		envName    string
+		layers     []Layer
+		wantValue  string
+		wantSource Source
+	}{
+		{name: "default", envName: "staging", layers: []Layer{MapLayer(SourceEnv, values(nil))}, wantValue: "info", wantSource: SourceDefault},
+		{name: "profile", layers: []Layer{MapLayer(SourceEnv, values(nil))}, wantValue: "debug", wantSource: SourceProfile},
+		{name: "file", layers: []Layer{MapLayer(SourceEnv, values(nil)), file}, wantValue: "warn", wantSource: SourceFile},
+		{name: "env", layers: []Layer{file, env}, wantValue: "error", wantSource: SourceEnv},
+		{name: "flag", layers: []Layer{file, env, flags}, wantValue: "trace", wantSource: SourceFlag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers := tt.layers
+			if tt.envName != "" {
+				layers = append(layers, MapLayer(SourceEnv, map[string]string{"ENV": tt.envName}))
+			}
+			config, err := LoadLayers(layers...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.App.LogLevel != tt.wantValue || config.SourceOf("LOG_LEVEL") != tt.wantSource {
+				t.Errorf("LOG_LEVEL = %q from %s, want %q from %s", config.App.LogLevel, config.SourceOf("LOG_LEVEL"), tt.wantValue, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestMapLayerSource(t *testing.T) {
+	config, err := LoadLayers(MapLayer(SourceFile, values(map[string]string{"RATE_LIMIT": "5"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source := config.SourceOf("RATE_LIMIT"); source != SourceFile {
+		t.Errorf("RATE_LIMIT source = %s, want %s", source, SourceFile)
+	}
+}
+
+func TestUnknownFileKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("app:\n  log_levle: warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := FileLayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadLayers(MapLayer(SourceEnv, values(nil)), file)
+	if reason := fieldErrors(t, err)["app.log_levle"]; !strings.Contains(reason, "unknown key in config file") {
+		t.Errorf("reason = %q, want the typo reported", reason)
+	}
+}
+
+// mapSecretProvider resolve the references from a map
+type mapSecretProvider map[string]string
+
+func (p mapSecretProvider) Resolve(_ context.Context, path string) (string, error) {
+	value, ok := p[path]
+	if !ok {
+		return "", fmt.Errorf("no secret at %s", path)
+	}
+	return value, nil
+}
+
+func TestSecrets(t *testing.T) {
+	RegisterSecretProvider("test", mapSecretProvider{"server/password": "from-provider"})
+
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		values     map[string]string
+		wantValue  string
+		wantSource Source
+		wantErr    string
+	}{
+		{name: "plain", values: map[string]string{"SERVER_PASSWORD": "plain"}, wantValue: "plain", wantSource: SourceEnv},
+		{name: "file", values: map[string]string{"SERVER_PASSWORD_FILE": secretFile}, wantValue: "from-file", wantSource: SourceEnv},
+		{name: "value wins over file", values: map[string]string{"SERVER_PASSWORD": "plain", "SERVER_PASSWORD_FILE": secretFile}, wantValue: "plain", wantSource: SourceEnv},
+		{name: "missing file", values: map[string]string{"SERVER_PASSWORD_FILE": filepath.Join(dir, "missing")}, wantErr: "SERVER_PASSWORD_FILE: cannot read secret file"},
+		{name: "reference", values: map[string]string{"SERVER_PASSWORD": "secret://test/server/password"}, wantValue: "from-provider", wantSource: SourceEnv},
+		{name: "unknown reference", values: map[string]string{"SERVER_PASSWORD": "secret://test/other"}, wantErr: "SERVER_PASSWORD: cannot resolve secret://test/other"},
+		{name: "unknown provider", values: map[string]string{"SERVER_PASSWORD": "secret://vault/server/password"}, wantErr: `no secret provider registered for "vault"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.values["SERVER_NAME"] = "api"
+			config, sources, err := loadTest(tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := config.Server.Password.Value(); got != tt.wantValue {
+				t.Errorf("password = %q, want %q", got, tt.wantValue)
+			}
+			if sources["SERVER_PASSWORD"] != tt.wantSource {
+				t.Errorf("source = %s, want %s", sources["SERVER_PASSWORD"], tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestFileSecretProviderStaysInRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "password"), []byte("inside"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewFileSecretProvider(root)
+	if value, err := provider.Resolve(context.Background(), "../../password"); err != nil || value != "inside" {
+		t.Errorf("value = %q, err = %v, want the file of the root", value, err)
+	}
+}
+
+func TestSecretRedaction(t *testing.T) {
+	config := mustLoad(t, map[string]string{"DATABASE_PASSWORD": "hunter2", "OAUTH_PRIVATE_KEY": "private-key"})
+
+	if config.Database.DatabasePassword.Value() != "hunter2" {
+		t.Fatalf("password = %q, want the plain value", config.Database.DatabasePassword.Value())
+	}
+
+	dump, err := json.Marshal(config.Values())
+	if err != nil {
+		t.Fatal(err)
+	}
+	printed := []string{
+		string(dump),
+		fmt.Sprintf("%v %+v %#v", config.Database, config.Database, config.Database.DatabasePassword),
+		fmt.Sprint(config.OAuth.PrivateKey),
+	}
+	for _, output := range printed {
+		if strings.Contains(output, "hunter2") || strings.Contains(output, "private-key") {
+			t.Errorf("secret leaked in %s", output)
+		}
+	}
+	if !strings.Contains(string(dump), `"env":"DATABASE_PASSWORD","value":"[REDACTED]"`) {
+		t.Errorf("dump = %s, want DATABASE_PASSWORD redacted", dump)
+	}
+
+	// The DSN embeds the password, it is a secret as well
+	if strings.Contains(fmt.Sprint(config.Database.DatabaseDSN), "hunter2") {
+		t.Error("secret leaked in the DSN")
+	}
+
+	// The reload diff is logged
+	next := mustLoad(t, map[string]string{"DATABASE_PASSWORD": "hunter3"})
+	for _, change := range Diff(config, next) {
+		if strings.Contains(change.String(), "hunter") {
+			t.Errorf("secret leaked in the change %s", change)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "none", args: nil, want: nil},
+		{name: "command", args: []string{"openapi", "openapi.json"}, want: []string{"openapi", "openapi.json"}},
+		{name: "command after the flags", args: []string{"--config", "app.yaml", "--log-level", "info", "openapi"}, want: []string{"openapi"}},
+		{name: "invalid flags", args: []string{"--unknown", "openapi"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Args(tt.args); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Args(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// GroupNetworks is a list of client networks applying to the requests under
+// a path
+type GroupNetworks struct {
+	// Route path, ":name" matches a single segment and "*" the rest of the path
+	Path     string
+	Networks []*net.IPNet
+}
+
+// Validate check the proxies and the IP lists are IPs or CIDRs
+func (n *networkConfig) Validate() []*FieldError {
+	var errs []*FieldError
+	for _, field := range []struct {
+		key   string
+		items []string
+	}{
+		{"TRUSTED_PROXIES", n.TrustedProxies},
+		{"IP_ALLOW", n.IPAllow},
+		{"IP_DENY", n.IPDeny},
+	} {
+		if _, err := parseNetworks(field.items); err != nil {
+			errs = append(errs, &FieldError{Key: field.key, Reason: err.Error()})
+		}
+	}
+	for _, field := range []struct {
+		key   string
+		items []string
+	}{
+		{"IP_ALLOW_GROUPS", n.IPAllowGroups},
+		{"IP_DENY_GROUPS", n.IPDenyGroups},
+	} {
+		if _, err := parseGroupNetworks(field.items); err != nil {
+			errs = append(errs, &FieldError{Key: field.key, Reason: err.Error()})
+		}
+	}
+	return errs
+}
+
+// TrustedNetworks return the networks of the trusted proxies
+func (n *networkConfig) TrustedNetworks() []*net.IPNet {
+	networks, _ := parseNetworks(n.TrustedProxies)
+	return networks
+}
+
+// AllowNetworks return the client networks allowed on the public server
+func (n *networkConfig) AllowNetworks() []*net.IPNet {
+	networks, _ := parseNetworks(n.IPAllow)
+	return networks
+}
+
+// DenyNetworks return the client networks refused on the public server
+func (n *networkConfig) DenyNetworks() []*net.IPNet {
+	networks, _ := parseNetworks(n.IPDeny)
+	return networks
+}
+
+// AllowGroups return the client networks allowed under a path of the public
+// server
+func (n *networkConfig) AllowGroups() []*GroupNetworks {
+	groups, _ := parseGroupNetworks(n.IPAllowGroups)
+	return groups
+}
+
+// DenyGroups return the client networks refused under a path of the public
+// server
+func (n *networkConfig) DenyGroups() []*GroupNetworks {
+	groups, _ := parseGroupNetworks(n.IPDenyGroups)
+	return groups
+}
+
+// Validate check the admin IP lists are IPs or CIDRs
+func (a *adminConfig) Validate() []*FieldError {
+	var errs []*FieldError
+	if _, err := parseNetworks(a.AdminIPAllow); err != nil {
+		errs = append(errs, &FieldError{Key: "ADMIN_IP_ALLOW", Reason: err.Error()})
+	}
+	if _, err := parseNetworks(a.AdminIPDeny); err != nil {
+		errs = append(errs, &FieldError{Key: "ADMIN_IP_DENY", Reason: err.Error()})
+	}
+	return errs
+}
+
+// AllowNetworks return the client networks allowed on the admin server
+func (a *adminConfig) AllowNetworks() []*net.IPNet {
+	networks, _ := parseNetworks(a.AdminIPAllow)
+	return networks
+}
+
+// DenyNetworks return the client networks refused on the admin server
+func (a *adminConfig) DenyNetworks() []*net.IPNet {
+	networks, _ := parseNetworks(a.AdminIPDeny)
+	return networks
+}
+
+// parseNetworks parse CIDRs, a bare IP is a network of one address
+func parseNetworks(items []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, item := range items {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP or CIDR", item)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR", item)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// parseGroupNetworks parse "<path>=<IP or CIDR> ...", the networks are space
+// separated
+func parseGroupNetworks(items []string) ([]*GroupNetworks, error) {
+	var groups []*GroupNetworks
+	for _, item := range items {
+		path, list, ok := strings.Cut(item, "=")
+		path = strings.TrimSpace(path)
+		if !ok || !strings.HasPrefix(path, "/") || strings.TrimSpace(list) == "" {
+			return nil, fmt.Errorf("%q must be <path>=<IP or CIDR> ...", item)
+		}
+		networks, err := parseNetworks(strings.Fields(list))
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, &GroupNetworks{Path: path, Networks: networks})
+	}
+	return groups, nil
+}
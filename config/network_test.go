@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseGroupNetworks(t *testing.T) {
+	tests := []struct {
+		items   []string
+		want    string
+		wantErr string
+	}{
+		{items: nil, want: "[]"},
+		{items: []string{"/v1/*=10.0.0.0/8 192.0.2.1", "/internal/*= 2001:db8::/32 "}, want: "[/v1/*: [10.0.0.0/8 192.0.2.1/32] /internal/*: [2001:db8::/32]]"},
+		{items: []string{"/v1/*"}, wantErr: `"/v1/*" must be <path>=<IP or CIDR> ...`},
+		{items: []string{"v1=10.0.0.0/8"}, wantErr: `"v1=10.0.0.0/8" must be <path>=<IP or CIDR> ...`},
+		{items: []string{"/v1/*="}, wantErr: `"/v1/*=" must be <path>=<IP or CIDR> ...`},
+		{items: []string{"/v1/*=10.0.0.0/33"}, wantErr: `"10.0.0.0/33" is not an IP or CIDR`},
+	}
+	for _, tt := range tests {
+		groups, err := parseGroupNetworks(tt.items)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("parseGroupNetworks(%q) error = %v, want %q", tt.items, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseGroupNetworks(%q) error = %v", tt.items, err)
+			continue
+		}
+		got := []string{}
+		for _, group := range groups {
+			got = append(got, fmt.Sprintf("%s: %v", group.Path, group.Networks))
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("parseGroupNetworks(%q) = %v, want %s", tt.items, got, tt.want)
+		}
+	}
+}
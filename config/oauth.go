@@ -0,0 +1,10 @@
+package config
+
+// Validate check the token verification is configured when it is required
+func (o *oauthConfig) Validate() []*FieldError {
+	var errs []*FieldError
+	if o.Enabled && o.PublicKey == "" && len(o.PublicKeyPaths) == 0 {
+		errs = append(errs, &FieldError{Key: "OAUTH_ENABLED", Reason: "requires OAUTH_PUBLIC_KEY or OAUTH_PUBLIC_KEY_PATHS to verify the tokens"})
+	}
+	return errs
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Validate check the Swagger UI directory holds the swagger-ui-dist assets
+func (o *openAPIConfig) Validate() []*FieldError {
+	if o.OpenAPISwaggerUIDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(o.OpenAPISwaggerUIDir, "swagger-ui-bundle.js")); err != nil {
+		return []*FieldError{{Key: "OPENAPI_SWAGGER_UI_DIR", Reason: "must contain the swagger-ui-dist assets, swagger-ui-bundle.js not found"}}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwaggerUIDir(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadLayers(MapLayer(SourceEnv, values(map[string]string{"OPENAPI_SWAGGER_UI_DIR": dir})))
+	if reasons := fieldErrors(t, err); reasons["OPENAPI_SWAGGER_UI_DIR"] == "" {
+		t.Errorf("errors = %v, want OPENAPI_SWAGGER_UI_DIR without assets refused", reasons)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "swagger-ui-bundle.js"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustLoad(t, map[string]string{"OPENAPI_SWAGGER_UI_DIR": dir})
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// Profile is a named set of defaults and guardrails selected by ENV
+	Profile struct {
+		Name string
+		// Defaults override the struct tag defaults, keyed by environment variable name
+		Defaults map[string]string
+		// Guardrails reject unsafe settings, the service refuses to boot on violation
+		Guardrails []*Guardrail
+	}
+	// Guardrail is a rule a loaded config must follow
+	Guardrail struct {
+		Key      string
+		Reason   string
+		Violated func(config *Config) bool
+	}
+)
+
+// Profiles available through ENV
+var Profiles = map[string]*Profile{
+	"local": {
+		Name: "local",
+		Defaults: map[string]string{
+			"LOG_LEVEL": "debug",
+		},
+	},
+	"staging": {
+		Name:     "staging",
+		Defaults: map[string]string{},
+	},
+	"production": {
+		Name: "production",
+		Defaults: map[string]string{
+			"MONITOR_ENABLED":            "false",
+			"OTEL_INSECURE_MODE":         "false",
+			"GRPC_REFLECTION":            "false",
+			"SHUTDOWN_PRE_STOP_DELAY":    "5s",
+			"ADMIN_PPROF_ENABLED":        "false",
+			"OPENAPI_SWAGGER_UI_ENABLED": "false",
+		},
+		Guardrails: []*Guardrail{
+			{
+				Key:    "CORS_ALLOW_ORIGINS",
+				Reason: `"*" is not allowed in production, list the allowed origins explicitly`,
+				Violated: func(config *Config) bool {
+					return strings.Contains(config.Fiber.CorsAllowOrigins, "*")
+				},
+			},
+			{
+				Key:    "OTEL_INSECURE_MODE",
+				Reason: "traces must be exported over TLS in production",
+				Violated: func(config *Config) bool {
+					return config.OpenTelemetry.OtelInsecureMode
+				},
+			},
+			{
+				Key:    "MONITOR_ENABLED",
+				Reason: "the /monitor route must stay disabled in production",
+				Violated: func(config *Config) bool {
+					return config.Fiber.MonitorEnabled
+				},
+			},
+			{
+				Key:    "RATE_LIMIT_BYPASS_SECRET",
+				Reason: "the signed rate limit bypass must stay disabled in production",
+				Violated: func(config *Config) bool {
+					return config.RateLimit.RateLimitBypassSecret != ""
+				},
+			},
+			{
+				Key:    "RATE_LIMIT_BYPASS_CIDRS",
+				Reason: "the rate limit bypass networks must stay empty in production",
+				Violated: func(config *Config) bool {
+					return len(config.RateLimit.RateLimitBypassCIDRs) > 0
+				},
+			},
+			{
+				Key:    "OPENAPI_VALIDATE_RESPONSES",
+				Reason: "response validation is meant for the tests, it turns contract drift into errors",
+				Violated: func(config *Config) bool {
+					return config.OpenAPI.OpenAPIValidateResponses
+				},
+			},
+		},
+	},
+}
+
+// profileLayer provide the profile defaults, it has the lowest precedence
+type profileLayer struct {
+	profile *Profile
+}
+
+func (l profileLayer) Source() Source {
+	return SourceProfile
+}
+
+func (l profileLayer) Lookup(field *Field) (string, bool) {
+	value, ok := l.profile.Defaults[field.Env]
+	return value, ok
+}
+
+// Check run the profile guardrails against a loaded config
+func (p *Profile) Check(config *Config) error {
+	loadErr := &LoadError{}
+	for _, guardrail := range p.Guardrails {
+		if guardrail.Violated(config) {
+			loadErr.Errors = append(loadErr.Errors, &FieldError{
+				Key:    guardrail.Key,
+				Reason: fmt.Sprintf("%s (profile %s, from %s)", guardrail.Reason, p.Name, config.SourceOf(guardrail.Key)),
+			})
+		}
+	}
+
+	if len(loadErr.Errors) > 0 {
+		return loadErr
+	}
+
+	return nil
+}
+
+// Summary describe the profile for the startup log
+func (p *Profile) Summary() string {
+	defaults := make([]string, 0, len(p.Defaults))
+	for key, value := range p.Defaults {
+		defaults = append(defaults, key+"="+value)
+	}
+	sort.Strings(defaults)
+
+	summary := fmt.Sprintf("Profile: %s, %d guardrails enforced", p.Name, len(p.Guardrails))
+	if len(defaults) > 0 {
+		summary += ", defaults: " + strings.Join(defaults, " ")
+	}
+	return summary
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// productionValues are safe production settings, the guardrails tests make
+// one of them unsafe
+var productionValues = map[string]string{
+	"ENV":                "production",
+	"CORS_ALLOW_ORIGINS": "https://example.com",
+}
+
+func TestProductionGuardrails(t *testing.T) {
+	tests := []struct {
+		env   string
+		value string
+	}{
+		{"CORS_ALLOW_ORIGINS", "*"},
+		{"CORS_ALLOW_ORIGINS", "https://example.com,*"},
+		{"OTEL_INSECURE_MODE", "true"},
+		{"MONITOR_ENABLED", "true"},
+		{"RATE_LIMIT_BYPASS_SECRET", "0123456789abcdef0123456789abcdef"},
+		{"RATE_LIMIT_BYPASS_CIDRS", "10.0.0.0/8"},
+		{"OPENAPI_VALIDATE_RESPONSES", "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			overrides := map[string]string{tt.env: tt.value}
+			for env, value := range productionValues {
+				if _, ok := overrides[env]; !ok {
+					overrides[env] = value
+				}
+			}
+
+			_, err := LoadLayers(MapLayer(SourceEnv, values(overrides)))
+			reason := fieldErrors(t, err)[tt.env]
+			if !strings.Contains(reason, "(profile production, from env)") {
+				t.Errorf("reason = %q, want the guardrail of the production profile", reason)
+			}
+
+			// The other profiles accept it
+			overrides["ENV"] = "staging"
+			if _, err := LoadLayers(MapLayer(SourceEnv, values(overrides))); err != nil {
+				t.Errorf("staging: err = %v, want none", err)
+			}
+		})
+	}
+}
+
+func TestProductionSafeSettings(t *testing.T) {
+	config := mustLoad(t, productionValues)
+	if config.Profile.Name != "production" {
+		t.Errorf("profile = %s, want production", config.Profile.Name)
+	}
+}
+
+func TestProfileDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     map[string]string
+		env        string
+		wantValue  string
+		wantSource Source
+	}{
+		{name: "local profile", values: map[string]string{}, env: "LOG_LEVEL", wantValue: "debug", wantSource: SourceProfile},
+		{name: "tag default without profile default", values: map[string]string{"ENV": "staging"}, env: "LOG_LEVEL", wantValue: "info", wantSource: SourceDefault},
+		{name: "env over local profile", values: map[string]string{"LOG_LEVEL": "warn"}, env: "LOG_LEVEL", wantValue: "warn", wantSource: SourceEnv},
+		{name: "production profile", values: productionValues, env: "SHUTDOWN_PRE_STOP_DELAY", wantValue: "5s", wantSource: SourceProfile},
+		{name: "production disables monitor", values: productionValues, env: "MONITOR_ENABLED", wantValue: "false", wantSource: SourceProfile},
+		{name: "production disables Swagger UI", values: productionValues, env: "OPENAPI_SWAGGER_UI_ENABLED", wantValue: "false", wantSource: SourceProfile},
+		{name: "Swagger UI outside production", values: map[string]string{"ENV": "staging"}, env: "OPENAPI_SWAGGER_UI_ENABLED", wantValue: "true", wantSource: SourceDefault},
+		{name: "env over production profile", values: map[string]string{"ENV": "production", "CORS_ALLOW_ORIGINS": "https://example.com", "SHUTDOWN_PRE_STOP_DELAY": "1s"}, env: "SHUTDOWN_PRE_STOP_DELAY", wantValue: "1s", wantSource: SourceEnv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := mustLoad(t, tt.values)
+
+			var got *Value
+			for _, value := range config.Values() {
+				if value.Env == tt.env {
+					got = value
+				}
+			}
+			if got == nil {
+				t.Fatalf("%s not found", tt.env)
+			}
+			if fmt.Sprint(got.Value) != tt.wantValue || got.Source != tt.wantSource {
+				t.Errorf("%s = %v from %s, want %s from %s", tt.env, got.Value, got.Source, tt.wantValue, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestUnknownProfile(t *testing.T) {
+	_, err := LoadLayers(MapLayer(SourceEnv, values(map[string]string{"ENV": "qa"})))
+	if reason := fieldErrors(t, err)["ENV"]; !strings.Contains(reason, `"qa" is not one of [local, staging, production]`) {
+		t.Errorf("reason = %q, want the profiles listed", reason)
+	}
+}
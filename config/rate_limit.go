@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// RouteCost is the rate limit cost of the requests matching a route
+type RouteCost struct {
+	Route
+	Cost int
+}
+
+// Validate check the tiers, API keys and costs can be parsed and refer to
+// known tiers
+func (r *rateLimitConfig) Validate() []*FieldError {
+	var errs []*FieldError
+
+	tiers, err := parseTiers(r.RateLimitTiers)
+	if err != nil {
+		errs = append(errs, &FieldError{Key: "RATE_LIMIT_TIERS", Reason: err.Error()})
+	} else if _, ok := tiers[r.RateLimitDefaultTier]; !ok {
+		errs = append(errs, &FieldError{Key: "RATE_LIMIT_DEFAULT_TIER", Reason: fmt.Sprintf("%q is not one of the RATE_LIMIT_TIERS", r.RateLimitDefaultTier)})
+	}
+
+	apiKeys, err := parseAPIKeys(r.RateLimitAPIKeys.Value())
+	if err != nil {
+		errs = append(errs, &FieldError{Key: "RATE_LIMIT_API_KEYS", Reason: err.Error()})
+	}
+	for _, tier := range apiKeys {
+		if _, ok := tiers[tier]; tiers != nil && !ok {
+			errs = append(errs, &FieldError{Key: "RATE_LIMIT_API_KEYS", Reason: fmt.Sprintf("tier %q is not one of the RATE_LIMIT_TIERS", tier)})
+			break
+		}
+	}
+
+	if _, err := parseCosts(r.RateLimitCosts); err != nil {
+		errs = append(errs, &FieldError{Key: "RATE_LIMIT_COSTS", Reason: err.Error()})
+	}
+
+	if secret := r.RateLimitBypassSecret.Value(); secret != "" && len(secret) < 32 {
+		errs = append(errs, &FieldError{Key: "RATE_LIMIT_BYPASS_SECRET", Reason: "must be at least 32 characters long"})
+	}
+	if _, err := parseNetworks(r.RateLimitBypassCIDRs); err != nil {
+		errs = append(errs, &FieldError{Key: "RATE_LIMIT_BYPASS_CIDRS", Reason: err.Error()})
+	}
+
+	return errs
+}
+
+// Tiers return the requests per window of each tier
+func (r *rateLimitConfig) Tiers() map[string]int {
+	tiers, _ := parseTiers(r.RateLimitTiers)
+	return tiers
+}
+
+// APIKeys return the tier of each API key
+func (r *rateLimitConfig) APIKeys() map[string]string {
+	apiKeys, _ := parseAPIKeys(r.RateLimitAPIKeys.Value())
+	return apiKeys
+}
+
+// Costs return the route costs in the configured order
+func (r *rateLimitConfig) Costs() []*RouteCost {
+	costs, _ := parseCosts(r.RateLimitCosts)
+	return costs
+}
+
+// BypassNetworks return the client networks that are not rate limited
+func (r *rateLimitConfig) BypassNetworks() []*net.IPNet {
+	networks, _ := parseNetworks(r.RateLimitBypassCIDRs)
+	return networks
+}
+
+func parseTiers(items []string) (map[string]int, error) {
+	tiers := map[string]int{}
+	for _, item := range items {
+		name, limit, ok := strings.Cut(item, ":")
+		n, err := strconv.Atoi(limit)
+		if !ok || name == "" || err != nil || n < 1 {
+			return nil, fmt.Errorf("%q must be <tier>:<limit> with a positive limit", item)
+		}
+		tiers[name] = n
+	}
+	return tiers, nil
+}
+
+func parseAPIKeys(value string) (map[string]string, error) {
+	apiKeys := map[string]string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		key, tier, ok := strings.Cut(item, ":")
+		if !ok || key == "" || tier == "" {
+			// The key itself is not reported, it is a secret
+			return nil, fmt.Errorf("every API key must be <key>:<tier>")
+		}
+		apiKeys[key] = tier
+	}
+	return apiKeys, nil
+}
+
+func parseCosts(items []string) ([]*RouteCost, error) {
+	var costs []*RouteCost
+	for _, item := range items {
+		route, cost, err := parseRoute(item)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(cost)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q cost must be an integer, 0 or more", item)
+		}
+		costs = append(costs, &RouteCost{Route: route, Cost: n})
+	}
+	return costs, nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Change is a configuration value that differs between two loaded configs
+type Change struct {
+	Key        string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("%s: %q → %q", c.Key, c.Old, c.New)
+}
+
+// Diff compare the current config with a freshly loaded one
+func Diff(current *Config, next *Config) []*Change {
+	values := map[string]reflect.Value{}
+	walk(reflect.ValueOf(next).Elem(), "", func(field *Field, _ reflect.StructTag, value reflect.Value) {
+		values[field.Env] = value
+	})
+
+	var changes []*Change
+	walk(reflect.ValueOf(current).Elem(), "", func(field *Field, tag reflect.StructTag, value reflect.Value) {
+		nextValue := values[field.Env]
+		if reflect.DeepEqual(value.Interface(), nextValue.Interface()) {
+			return
+		}
+		changes = append(changes, &Change{
+			Key:        field.Env,
+			Old:        fmt.Sprint(value.Interface()),
+			New:        fmt.Sprint(nextValue.Interface()),
+			Reloadable: tag.Get(tagReload) == "true",
+		})
+	})
+
+	return changes
+}
+
+// Merge return a copy of c with the reloadable values of next and the
+// derived middleware configs rebuilt. Values requiring a restart are kept, c is
+// left untouched so it can be read while the copy is built.
+func (c *Config) Merge(next *Config) *Config {
+	merged := c.clone()
+
+	values := map[string]reflect.Value{}
+	walk(reflect.ValueOf(next).Elem(), "", func(field *Field, _ reflect.StructTag, value reflect.Value) {
+		values[field.Env] = value
+	})
+
+	walk(reflect.ValueOf(merged).Elem(), "", func(field *Field, tag reflect.StructTag, value reflect.Value) {
+		if tag.Get(tagReload) == "true" {
+			value.Set(values[field.Env])
+			merged.Sources[field.Env] = next.SourceOf(field.Env)
+		}
+	})
+
+	NewFiberConfig(merged.App, merged.Network, merged.Fiber)
+	return merged
+}
+
+// clone return a copy of c whose sections are copies as well
+func (c *Config) clone() *Config {
+	clone := *c
+	cloneSections(reflect.ValueOf(&clone).Elem())
+
+	clone.Sources = make(map[string]Source, len(c.Sources))
+	for env, source := range c.Sources {
+		clone.Sources[env] = source
+	}
+	return &clone
+}
+
+// cloneSections replace the pointers to the sections of the config package in
+// the struct v by pointers to copies
+func cloneSections(v reflect.Value) {
+	pkgPath := reflect.TypeOf(Config{}).PkgPath()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !v.Type().Field(i).IsExported() || field.Kind() != reflect.Pointer || field.IsNil() {
+			continue
+		}
+		if elem := field.Type().Elem(); elem.Kind() == reflect.Struct && elem.PkgPath() == pkgPath {
+			section := reflect.New(elem)
+			section.Elem().Set(field.Elem())
+			cloneSections(section.Elem())
+			field.Set(section)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	current := mustLoad(t, nil)
+	next := mustLoad(t, map[string]string{"RATE_LIMIT": "5", "HTTP_PORT": "9000"})
+
+	changes := map[string]*Change{}
+	for _, change := range Diff(current, next) {
+		changes[change.Key] = change
+	}
+	if len(changes) != 2 {
+		t.Fatalf("changes = %v, want RATE_LIMIT and HTTP_PORT", changes)
+	}
+	if change := changes["RATE_LIMIT"]; change == nil || !change.Reloadable || change.Old != "60" || change.New != "5" {
+		t.Errorf("RATE_LIMIT change = %+v, want reloadable 60 → 5", change)
+	}
+	if change := changes["HTTP_PORT"]; change == nil || change.Reloadable {
+		t.Errorf("HTTP_PORT change = %+v, want a change requiring a restart", change)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	current := mustLoad(t, nil)
+	next := mustLoad(t, map[string]string{
+		"RATE_LIMIT":         "5",
+		"CORS_ALLOW_ORIGINS": "https://example.com",
+		"HTTP_PORT":          "9000",
+	})
+
+	merged := current.Merge(next)
+
+	if merged.Fiber.RateLimit != 5 {
+		t.Errorf("RATE_LIMIT = %d, want 5", merged.Fiber.RateLimit)
+	}
+	if merged.App.HttpPort != 8000 {
+		t.Errorf("HTTP_PORT = %d, want 8000, it requires a restart", merged.App.HttpPort)
+	}
+	if got := merged.Fiber.Middleware.Cors.AllowOrigins; got != "https://example.com" {
+		t.Errorf("cors AllowOrigins = %q, want the derived config rebuilt", got)
+	}
+
+	// The running config is read concurrently, it must not change
+	if current.Fiber.RateLimit != 60 || current.Fiber.Middleware.Cors.AllowOrigins != "*" {
+		t.Errorf("current config modified: RATE_LIMIT = %d, cors = %q", current.Fiber.RateLimit, current.Fiber.Middleware.Cors.AllowOrigins)
+	}
+	if current.Fiber == merged.Fiber || current.Fiber.Middleware == merged.Fiber.Middleware || current.App == merged.App {
+		t.Error("merged config shares sections with the current config")
+	}
+}
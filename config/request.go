@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// RouteTimeout is the deadline of the requests matching a route
+type RouteTimeout struct {
+	Route
+	Timeout time.Duration
+}
+
+// Validate check the route timeouts can be parsed
+func (r *requestConfig) Validate() []*FieldError {
+	if _, err := parseTimeouts(r.RequestTimeoutRoutes); err != nil {
+		return []*FieldError{{Key: "REQUEST_TIMEOUT_ROUTES", Reason: err.Error()}}
+	}
+	return nil
+}
+
+// RouteTimeouts return the route timeouts in the configured order
+func (r *requestConfig) RouteTimeouts() []*RouteTimeout {
+	timeouts, _ := parseTimeouts(r.RequestTimeoutRoutes)
+	return timeouts
+}
+
+func parseTimeouts(items []string) ([]*RouteTimeout, error) {
+	var timeouts []*RouteTimeout
+	for _, item := range items {
+		route, timeout, err := parseRoute(item)
+		if err != nil {
+			return nil, err
+		}
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%q timeout must be a duration, e.g. 30s, 0 disables it", item)
+		}
+		timeouts = append(timeouts, &RouteTimeout{Route: route, Timeout: d})
+	}
+	return timeouts, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Route match requests by method and path, they are set in config as
+// "<METHOD> <path>[?<query param>]"
+type Route struct {
+	Method string
+	// Route path, ":name" matches a single segment and "*" the rest of the path
+	Path string
+	// Query parameter that must be set for the route to match, e.g. search
+	Query string
+}
+
+func (r Route) String() string {
+	if r.Query != "" {
+		return r.Method + " " + r.Path + "?" + r.Query
+	}
+	return r.Method + " " + r.Path
+}
+
+// parseRoute parse "<METHOD> <path>[?<query param>]:<value>", the value is
+// after the last colon
+func parseRoute(item string) (Route, string, error) {
+	i := strings.LastIndex(item, ":")
+	if i < 0 {
+		return Route{}, "", fmt.Errorf("%q must be <METHOD> <path>[?<query param>]:<value>", item)
+	}
+	route, value := item[:i], item[i+1:]
+	method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+	if !ok || !strings.HasPrefix(path, "/") || value == "" {
+		return Route{}, "", fmt.Errorf("%q must be <METHOD> <path>[?<query param>]:<value>", item)
+	}
+	path, query, _ := strings.Cut(path, "?")
+	return Route{Method: strings.ToUpper(method), Path: path, Query: query}, value, nil
+}
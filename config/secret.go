@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret reference scheme, e.g. secret://vault/database/password
+const secretScheme = "secret://"
+
+const redacted = "[REDACTED]"
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// Secret is a sensitive configuration value. It is redacted whenever it is
+// printed, logged or marshalled, use Value to read the plain value.
+//
+// Secret fields can also be set from a file with the _FILE suffix
+// (DATABASE_PASSWORD_FILE=/run/secrets/db-password) or with a
+// secret://provider/path reference resolved by a registered SecretProvider.
+type Secret string
+
+// Value return the plain secret value
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type (
+	// SecretProvider resolve secret://<provider>/<path> references
+	SecretProvider interface {
+		Resolve(ctx context.Context, path string) (string, error)
+	}
+	// FileSecretProvider read secrets from files under a root directory
+	FileSecretProvider struct {
+		Root string
+	}
+)
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		"file": NewFileSecretProvider("/run/secrets"),
+	}
+	// Timeout for resolving a single secret reference
+	SecretResolveTimeout = 10 * time.Second
+)
+
+// RegisterSecretProvider make a provider available to secret://<name>/...
+// references, it replaces any provider registered with the same name
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[name] = provider
+}
+
+// ResolveSecret resolve a secret://provider/path reference, other values are
+// returned unchanged. Errors never contain the resolved value.
+func ResolveSecret(ctx context.Context, ref string) (string, error) {
+	if !strings.HasPrefix(ref, secretScheme) {
+		return ref, nil
+	}
+
+	name, path, _ := strings.Cut(strings.TrimPrefix(ref, secretScheme), "/")
+	secretProvidersMu.RLock()
+	provider, ok := secretProviders[name]
+	secretProvidersMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no secret provider registered for %q", name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SecretResolveTimeout)
+	defer cancel()
+	value, err := provider.Resolve(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %w", ref, err)
+	}
+
+	return value, nil
+}
+
+// NewFileSecretProvider is the constructor function for FileSecretProvider
+func NewFileSecretProvider(root string) *FileSecretProvider {
+	return &FileSecretProvider{Root: root}
+}
+
+func (p *FileSecretProvider) Resolve(_ context.Context, path string) (string, error) {
+	// Keep the path inside the root directory
+	clean := filepath.Clean("/" + path)
+	return readSecretFile(filepath.Join(p.Root, clean))
+}
+
+// readSecretFile read a mounted secret file, the trailing newline is removed
+func readSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// fileVariant return the field holding the path of a file containing the value
+func (f *Field) fileVariant() *Field {
+	return &Field{
+		Env:  f.Env + "_FILE",
+		Key:  f.Key + "_file",
+		Flag: f.Flag + "-file",
+	}
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Source tell where a configuration value came from
+type Source string
+
+// Configuration sources, from the lowest to the highest precedence
+const (
+	SourceDefault Source = "default"
+	SourceProfile Source = "profile"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+type (
+	// Layer is a single configuration source. Layers passed later to Load take
+	// precedence over the earlier ones.
+	Layer interface {
+		Source() Source
+		Lookup(field *Field) (string, bool)
+	}
+	// Field describe a configuration key under the different source naming
+	// conventions
+	Field struct {
+		// Env is the environment variable name, e.g. DATABASE_HOST
+		Env string
+		// Key is the config file key, e.g. database.host
+		Key string
+		// Flag is the command-line flag name, e.g. database-host
+		Flag string
+	}
+)
+
+func newField(section string, env string) *Field {
+	name := strings.ToLower(env)
+	key := name
+	if section != "" {
+		key = section + "." + strings.TrimPrefix(name, section+"_")
+	}
+
+	return &Field{
+		Env:  env,
+		Key:  key,
+		Flag: strings.ReplaceAll(name, "_", "-"),
+	}
+}
+
+// Environment variables --------------------------------------------------------------
+
+type envLayer struct{}
+
+// EnvLayer read values from environment variables, empty values are ignored
+func EnvLayer() Layer {
+	return envLayer{}
+}
+
+func (envLayer) Source() Source {
+	return SourceEnv
+}
+
+func (envLayer) Lookup(field *Field) (string, bool) {
+	value := os.Getenv(field.Env)
+	return value, value != ""
+}
+
+// Values ---------------------------------------------------------------------------
+
+type mapLayer struct {
+	source Source
+	values map[string]string
+}
+
+// MapLayer read values from a map keyed by environment variable name, e.g. the
+// configuration of a test. The values are reported as coming from source.
+func MapLayer(source Source, values map[string]string) Layer {
+	return mapLayer{source: source, values: values}
+}
+
+func (l mapLayer) Source() Source {
+	return l.source
+}
+
+func (l mapLayer) Lookup(field *Field) (string, bool) {
+	value, ok := l.values[field.Env]
+	return value, ok && value != ""
+}
+
+// Config file ------------------------------------------------------------------------
+
+type fileLayer struct {
+	path   string
+	values map[string]string
+}
+
+// FileLayer read values from a YAML (.yaml, .yml) or TOML (.toml) file whose
+// top-level tables are the config sections, e.g.
+//
+//	database:
+//	  host: localhost
+//	  port: 5432
+func FileLayer(path string) (Layer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	tree := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return nil, fmt.Errorf("config file: unsupported format %q, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten("", tree, values)
+
+	return &fileLayer{path: path, values: values}, nil
+}
+
+func flatten(prefix string, node interface{}, values map[string]string) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(key, child, values)
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		values[prefix] = strings.Join(items, ",")
+	case nil:
+		values[prefix] = ""
+	default:
+		values[prefix] = fmt.Sprint(v)
+	}
+}
+
+func (l *fileLayer) Source() Source {
+	return SourceFile
+}
+
+func (l *fileLayer) Lookup(field *Field) (string, bool) {
+	value, ok := l.values[field.Key]
+	return value, ok && value != ""
+}
+
+// Unknown return the file keys that are not in the known config keys
+func (l *fileLayer) Unknown(known map[string]bool) []string {
+	var unknown []string
+	for key := range l.values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// Command-line flags -----------------------------------------------------------------
+
+type flagLayer struct {
+	values map[string]string
+}
+
+// FlagLayer parse command-line arguments, every config field is available as a
+// flag named after its environment variable (DATABASE_HOST → --database-host).
+// The --config flag is returned separately as the config file path.
+func FlagLayer(target interface{}, args []string) (Layer, string, error) {
+	layer, configFile, _, err := parseFlags(target, args, os.Stderr)
+	return layer, configFile, err
+}
+
+// Args return the command-line arguments after the flags, e.g. the command of
+// `<binary> --config app.yaml openapi`. They are empty when the flags are
+// invalid, LoadConfig reports them.
+func Args(args []string) []string {
+	_, _, rest, err := parseFlags(&Config{}, args, io.Discard)
+	if err != nil {
+		return nil
+	}
+	return rest
+}
+
+// parseFlags parse the flags of target, the usage and the errors are written
+// to output
+func parseFlags(target interface{}, args []string, output io.Writer) (Layer, string, []string, error) {
+	flagSet := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flagSet.SetOutput(output)
+	configFile := flagSet.String("config", "", "path to a YAML or TOML config file")
+
+	for _, field := range Fields(target) {
+		flagSet.String(field.Flag, "", fmt.Sprintf("overrides %s (%s)", field.Env, field.Key))
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, "", nil, err
+	}
+
+	values := map[string]string{}
+	flagSet.Visit(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+
+	return &flagLayer{values: values}, *configFile, flagSet.Args(), nil
+}
+
+func (l *flagLayer) Source() Source {
+	return SourceFlag
+}
+
+func (l *flagLayer) Lookup(field *Field) (string, bool) {
+	value, ok := l.values[field.Flag]
+	return value, ok
+}
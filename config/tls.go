@@ -0,0 +1,42 @@
+package config
+
+import "crypto/tls"
+
+// Validate check the settings that depend on each other
+func (t *tlsConfig) Validate() []*FieldError {
+	var errs []*FieldError
+	if (t.TLSCertFile == "") != (t.TLSKeyFile == "") {
+		errs = append(errs, &FieldError{Key: "TLS_CERT_FILE", Reason: "TLS_CERT_FILE and TLS_KEY_FILE must be set together"})
+	}
+	if t.TLSClientCAFile != "" && t.TLSCertFile == "" {
+		errs = append(errs, &FieldError{Key: "TLS_CLIENT_CA_FILE", Reason: "requires TLS_CERT_FILE and TLS_KEY_FILE, mTLS is only available over HTTPS"})
+	}
+
+	return errs
+}
+
+// Enabled report whether the servers listen over TLS
+func (t *tlsConfig) Enabled() bool {
+	return t.TLSCertFile != "" && t.TLSKeyFile != ""
+}
+
+// ClientAuth return how client certificates are verified, they are not
+// requested without a client CA bundle
+func (t *tlsConfig) ClientAuth() tls.ClientAuthType {
+	switch {
+	case t.TLSClientCAFile == "":
+		return tls.NoClientCert
+	case t.TLSClientAuth == "optional":
+		return tls.VerifyClientCertIfGiven
+	default:
+		return tls.RequireAndVerifyClientCert
+	}
+}
+
+// MinVersion return the lowest accepted TLS version
+func (t *tlsConfig) MinVersion() uint16 {
+	if t.TLSMinVersion == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
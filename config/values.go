@@ -0,0 +1,35 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// Value is a configuration value and where it came from
+type Value struct {
+	Key        string      `json:"key"`
+	Env        string      `json:"env"`
+	Value      interface{} `json:"value"`
+	Source     Source      `json:"source"`
+	Reloadable bool        `json:"reloadable"`
+}
+
+// Values list every configuration value with its source, secrets are redacted
+func (c *Config) Values() []*Value {
+	var values []*Value
+	walk(reflect.ValueOf(c).Elem(), "", func(field *Field, tag reflect.StructTag, value reflect.Value) {
+		v := value.Interface()
+		if d, ok := v.(time.Duration); ok {
+			v = d.String()
+		}
+		values = append(values, &Value{
+			Key:        field.Key,
+			Env:        field.Env,
+			Value:      v,
+			Source:     c.SourceOf(field.Env),
+			Reloadable: tag.Get(tagReload) == "true",
+		})
+	})
+
+	return values
+}
@@ -1,61 +1,139 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"log"
+	"os"
 	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/app/http_server"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/component"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/database"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/exceptions"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/health"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/metrics"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/repositories"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/routes"
 	"github.com/gofiber/fiber/v2"
 )
 
 func main() {
+	// Command after the flags, e.g. `<binary> --config app.yaml openapi [file]`
+	if args := config.Args(os.Args[1:]); len(args) > 0 {
+		if args[0] != "openapi" {
+			log.Fatalf("unknown command %q, the only command is openapi", args[0])
+		}
+		// Export the OpenAPI document and exit
+		exportOpenAPI(config.NewConfig(config.MapLayer(config.SourceDefault, exportValues)), args[1:])
+		return
+	}
+
 	// Load environment variables
 	globalConfig := config.NewConfig()
 
-	// Initialize connection to database
-	dbClient := database.Initialize(globalConfig)
+	// Register the dependencies, they are started after the components they
+	// depend on and stopped in the reverse order
+	components := component.NewRegistry(globalConfig.App.ComponentStartTimeout, globalConfig.App.ComponentStopTimeout)
 
-	// Initialize connection to cache
-	redisClient := cache.Initialize(globalConfig)
-	cacher := cache.NewCacher(
-		redisClient,
-		cache.WithPrefix(globalConfig.Redis.RedisCachePrefix),
-		cache.WithExpired(time.Minute*time.Duration(globalConfig.Redis.RedisCacheDuration)),
-	)
+	// Sentry client for error logging and tracing
+	sentryComponent := exceptions.NewComponent(globalConfig)
+	components.Register(sentryComponent)
 
-	// Initialize OpenTelemetry tracing
-	traceProvider, tracer := tracing.InitTracer(globalConfig)
+	// OpenTelemetry tracing
+	tracingComponent := tracing.NewComponent(globalConfig)
+	components.Register(tracingComponent)
 
-	// Initialize Sentry client for error logging and tracing
-	exceptions.SentryInitialize(globalConfig)
+	// Connection to database
+	databaseComponent := database.NewComponent(globalConfig)
+	components.Register(databaseComponent, component.DependsOn(exceptions.ComponentName, tracing.ComponentName))
+
+	// Connection to cache
+	cacheComponent := cache.NewComponent(globalConfig)
+	components.Register(cacheComponent, component.DependsOn(exceptions.ComponentName, tracing.ComponentName))
+
+	if err := components.Start(context.Background()); err != nil {
+		components.Stop(context.Background())
+		log.Fatal(err)
+	}
 
 	// Create microservice instance
 	httpServer := http_server.NewHttpServer(
 		globalConfig,
-		dbClient,
-		cacher,
-		traceProvider,
-		tracer,
+		components,
+		cacheComponent.Cacher(),
+		cacheComponent.Storage(),
+		tracingComponent.Tracer(),
 	)
 
+	// Dependencies checked by the readiness and startup probes, the service
+	// keeps serving when traces cannot be exported and starts while Redis is
+	// unavailable
+	httpServer.Readiness().Register(databaseComponent)
+	httpServer.Readiness().Register(cacheComponent)
+	httpServer.Readiness().Register(tracingComponent, health.Optional())
+	httpServer.Startup().Register(databaseComponent)
+	httpServer.Startup().Register(cacheComponent, health.Optional())
+
+	// Data access
+	repos := repositories.NewRepositories(databaseComponent.DB(), tracingComponent.Tracer())
+
+	// Database connection pool metrics
+	if sqlDB, err := databaseComponent.DB().DB(); err == nil {
+		metrics.RegisterDatabase(sqlDB, globalConfig.Database.DatabaseName)
+	}
+
 	// Start http server
-	httpRouting(httpServer)
+	httpRouting(httpServer, repos)
+
+	// Register gRPC services
+	routes.GRPCRoutes(httpServer, repos)
+
+	// Register the internal admin routes
+	routes.AdminRoutes(httpServer, repos)
 
 	// Microservice start up
 	httpServer.StartServer()
 }
 
-func httpRouting(s *http_server.HttpServer) {
+func httpRouting(s *http_server.HttpServer, repos *repositories.Repositories) {
 	zone, _ := time.Now().Zone()
 	if !fiber.IsChild() {
 		log.Println("HTTP service is running")
 		log.Println("[Timezone]:", zone)
 	}
-	routes.HTTPRoutes(s)
+	routes.HTTPRoutes(s, repos)
+}
+
+// exportValues are the connection settings of the OpenAPI export when they
+// are not configured, the document does not depend on them
+var exportValues = map[string]string{
+	"DATABASE_HOST": "localhost",
+	"DATABASE_NAME": "export",
+	"DATABASE_USER": "export",
+	"REDIS_HOST":    "localhost",
+}
+
+// exportOpenAPI write the OpenAPI document of the HTTP routes to the file, or
+// to stdout, the routes are registered without connecting to the dependencies
+func exportOpenAPI(config *config.Config, args []string) {
+	httpServer := http_server.NewHttpServer(config, nil, nil, nil, nil)
+	routes.HTTPRoutes(httpServer, &repositories.Repositories{})
+
+	document, err := json.MarshalIndent(httpServer.OpenAPI().Document(), "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	document = append(document, '\n')
+
+	if len(args) == 0 {
+		os.Stdout.Write(document)
+		return
+	}
+	if err := os.WriteFile(args[0], document, 0644); err != nil {
+		log.Fatal(err)
+	}
 }
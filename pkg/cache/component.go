@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ComponentName is the name of the cache component
+const ComponentName = "cache"
+
+// Component manage the Redis connection of the cacher
+type Component struct {
+	config  *config.Config
+	redis   *redis.Client
+	cacher  *Cache
+	storage *Storage
+}
+
+// NewComponent is the constructor function for the cache component, Redis is
+// connected by Start
+func NewComponent(config *config.Config) *Component {
+	return &Component{config: config}
+}
+
+func (c *Component) Name() string {
+	return ComponentName
+}
+
+// Start connect to Redis. The service starts while Redis is unavailable, the
+// readiness probe fails until it answers and the storage keeps the middleware
+// state in memory meanwhile.
+func (c *Component) Start(ctx context.Context) error {
+	c.redis = Initialize(c.config)
+	c.cacher = NewCacher(
+		c.redis,
+		WithPrefix(c.config.Redis.RedisCachePrefix),
+		WithExpired(time.Minute*time.Duration(c.config.Redis.RedisCacheDuration)),
+	)
+	// Under <prefix>:storage, or storage without a cache prefix
+	c.storage = NewStorage(c.redis, c.config.Redis.RedisCachePrefix).Prefixed("storage")
+
+	if err := c.redis.Ping(ctx).Err(); err != nil {
+		log.Printf("[WARN] Redis unavailable at start, the cache is not ready: %v", err)
+	}
+	return nil
+}
+
+func (c *Component) Stop(ctx context.Context) error {
+	return c.redis.Close()
+}
+
+func (c *Component) Health(ctx context.Context) error {
+	_, err := c.Check(ctx)
+	return err
+}
+
+// Check send a PING to Redis, the details are the connection pool statistics
+func (c *Component) Check(ctx context.Context) (any, error) {
+	stats := c.redis.PoolStats()
+	details := fiber.Map{
+		"total_connections": stats.TotalConns,
+		"idle_connections":  stats.IdleConns,
+		"stale_connections": stats.StaleConns,
+		"hits":              stats.Hits,
+		"misses":            stats.Misses,
+		"timeouts":          stats.Timeouts,
+	}
+	return details, c.redis.Ping(ctx).Err()
+}
+
+// Storage return the middleware storage, nil until the component is started
+func (c *Component) Storage() *Storage {
+	return c.storage
+}
+
+// Cacher return the cacher, nil until the component is started
+func (c *Component) Cacher() Cacher {
+	if c.cacher == nil {
+		return nil
+	}
+	return c.cacher
+}
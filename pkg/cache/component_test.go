@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+)
+
+func TestComponentStartWithoutRedis(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	// A port nobody listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	cfg, err := config.LoadLayers(config.MapLayer(config.SourceEnv, map[string]string{
+		"HTTP_PORT":     "8000",
+		"APP_NAME":      "Stream - User Service",
+		"SERVICE_NAME":  "user-service",
+		"REDIS_HOST":    "127.0.0.1",
+		"REDIS_PORT":    strconv.Itoa(port),
+		"DATABASE_HOST": "localhost",
+		"DATABASE_NAME": "test",
+		"DATABASE_USER": "test",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewComponent(cfg)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start() = %v, want the service to start without Redis", err)
+	}
+	defer c.Stop(context.Background())
+
+	if err := c.Health(context.Background()); err == nil {
+		t.Error("Health() = nil, want the readiness to fail without Redis")
+	}
+	if c.Cacher() == nil || c.Storage() == nil {
+		t.Fatal("Cacher() and Storage() must be set once started")
+	}
+
+	// The storage falls back to memory
+	storage := c.Storage().Prefixed("test")
+	if err := storage.Set("key", []byte("value"), 0); err != nil {
+		t.Fatalf("Set() = %v", err)
+	}
+	if value, err := storage.Get("key"); err != nil || string(value) != "value" {
+		t.Errorf("Get() = %q, %v, want the value kept in memory", value, err)
+	}
+}
@@ -4,56 +4,59 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
-	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils/color"
 	"github.com/go-redis/redis/v8"
-	"github.com/gofiber/fiber/v2"
 )
 
-type Cache struct {
-	redis *redis.Client
-	// redisCluster *redis.ClusterClient
-	tags []string
-
-	prefix  string
-	expired time.Duration
-}
+type (
+	// Cacher store JSON values under tags, Cache implement it with Redis
+	Cacher interface {
+		Get(ctx context.Context, key string, val interface{}) error
+		Set(ctx context.Context, key string, val interface{}) error
+		// Tag return a cacher whose Set and Flush apply to the tags
+		Tag(tag ...string) Cacher
+		Flush(ctx context.Context) error
+		Delete(ctx context.Context, keys ...string) error
+		SetExpired(exp time.Duration)
+	}
+	Cache struct {
+		redis *redis.Client
+		// redisCluster *redis.ClusterClient
+		tags []string
+
+		prefix string
+		// Shared by the tagged copies so that SetExpired apply to all of them
+		expired *atomic.Int64
+	}
+)
 
 func Initialize(config *config.Config) *redis.Client {
 	return redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", config.Redis.RedisHost, config.Redis.RedisPort),
+		Addr:     fmt.Sprintf("%s:%d", config.Redis.RedisHost, config.Redis.RedisPort),
 		Username: config.Redis.RedisUsername,
-		Password: config.Redis.RedisPassword,
+		Password: config.Redis.RedisPassword.Value(),
 	})
 }
 
+// NewCacher return a cacher on the Redis client, the connection is checked by
+// the cache component when it starts
 func NewCacher(redisClient *redis.Client, opts ...Option) *Cache {
-	if !fiber.IsChild() {
-		log.Println("Redis connecting...")
-	}
-
-	_, err := redisClient.Ping(context.Background()).Result()
-	if err != nil {
-		panic(err)
-	}
-
-	if !fiber.IsChild() {
-		log.Println("Redis client connected", color.Format(color.GREEN, "successfully!"))
-	}
-
 	o := &Options{}
 	for _, opt := range opts {
 		opt(o)
 	}
 
-	return &Cache{
+	c := &Cache{
 		redis:   redisClient,
 		prefix:  o.prefix,
-		expired: o.expired,
+		expired: &atomic.Int64{},
 	}
+	c.SetExpired(o.expired)
+
+	return c
 }
 
 type Options struct {
@@ -75,9 +78,20 @@ func WithExpired(exp time.Duration) Option {
 	}
 }
 
-func (c *Cache) Tag(tag ...string) *Cache {
-	c.tags = tag
-	return c
+// SetExpired change the expiration of the values set from now on
+func (c *Cache) SetExpired(exp time.Duration) {
+	c.expired.Store(int64(exp))
+}
+
+// Tag return a copy of the cache with the tags, the cache itself is shared by
+// concurrent requests and is left untouched
+func (c *Cache) Tag(tag ...string) Cacher {
+	return &Cache{
+		redis:   c.redis,
+		tags:    tag,
+		prefix:  c.prefix,
+		expired: c.expired,
+	}
 }
 
 func (c *Cache) Get(ctx context.Context, key string, val interface{}) error {
@@ -123,7 +137,7 @@ func (c *Cache) Set(ctx context.Context, key string, val interface{}) error {
 			return err
 		}
 
-		err = p.Set(ctx, key, string(value), c.expired).Err()
+		err = p.Set(ctx, key, string(value), time.Duration(c.expired.Load())).Err()
 		if err != nil {
 			fmt.Println("p.Set err:", err)
 			return err
@@ -168,6 +182,22 @@ func (c *Cache) Flush(ctx context.Context) error {
 	return nil
 }
 
+// Delete remove the given keys
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if len(c.prefix) > 0 {
+		prefixed := make([]string, len(keys))
+		for i, key := range keys {
+			prefixed[i] = c.prefix + ":" + key
+		}
+		keys = prefixed
+	}
+
+	if err := c.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("cache: delete %v: %w", keys, err)
+	}
+	return nil
+}
+
 func (c *Cache) Close() {
 	c.redis.Close()
 }
@@ -0,0 +1,328 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// Time between two attempts to use Redis again once it failed
+	storageRetryInterval = 5 * time.Second
+	// Deadline of the Redis calls, the middlewares run on every request
+	storageTimeout = 500 * time.Millisecond
+	// Deadline of Reset, it scans every key under the prefix
+	storageResetTimeout = 10 * time.Second
+)
+
+type (
+	// Storage implement fiber.Storage on Redis so that the middleware state
+	// (rate limiter counters, ...) is shared by the processes and replicas. The
+	// keys are set under a prefix. When Redis fails the values are kept in
+	// memory until it is available again.
+	Storage struct {
+		redis    *redis.Client
+		prefix   string
+		fallback *MemoryStorage
+		// Unix nano time of the next Redis attempt, 0 while Redis is available.
+		// Shared by the prefixed copies.
+		retryAt *atomic.Int64
+	}
+	// MemoryStorage implement fiber.Storage in memory, for a single process
+	MemoryStorage struct {
+		mu      sync.Mutex
+		values  map[string]memoryValue
+		sweepAt time.Time
+	}
+
+	memoryValue struct {
+		data    []byte
+		expires time.Time
+	}
+)
+
+// NewStorage return a storage on the Redis client whose keys are set under
+// prefix
+func NewStorage(client *redis.Client, prefix string) *Storage {
+	return &Storage{
+		redis:    client,
+		prefix:   prefix,
+		fallback: NewMemoryStorage(),
+		retryAt:  &atomic.Int64{},
+	}
+}
+
+// Prefixed return a storage sharing the connection whose keys are set under
+// prefix:name, each middleware gets its own
+func (s *Storage) Prefixed(name string) *Storage {
+	return &Storage{
+		redis:    s.redis,
+		prefix:   s.key(name),
+		fallback: NewMemoryStorage(),
+		retryAt:  s.retryAt,
+	}
+}
+
+func (s *Storage) Get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+	if s.available() {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+
+		val, err := s.redis.Get(ctx, s.key(key)).Bytes()
+		if err == nil || errors.Is(err, redis.Nil) {
+			s.recovered()
+			return val, nil
+		}
+		s.failed(err)
+	}
+
+	return s.fallback.Get(key)
+}
+
+func (s *Storage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+	if s.available() {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+
+		err := s.redis.Set(ctx, s.key(key), val, exp).Err()
+		if err == nil {
+			s.recovered()
+			return nil
+		}
+		s.failed(err)
+	}
+
+	return s.fallback.Set(key, val, exp)
+}
+
+func (s *Storage) Delete(key string) error {
+	if key == "" {
+		return nil
+	}
+	s.fallback.Delete(key)
+	if s.available() {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+
+		if err := s.redis.Del(ctx, s.key(key)).Err(); err != nil {
+			s.failed(err)
+			return err
+		}
+		s.recovered()
+	}
+	return nil
+}
+
+// Increment add by to the counter and return its new value, the expiration is
+// renewed on every increment. It is atomic across processes.
+func (s *Storage) Increment(key string, by int, exp time.Duration) (int, error) {
+	if s.available() {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+
+		var count *redis.IntCmd
+		_, err := s.redis.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			count = p.IncrBy(ctx, s.key(key), int64(by))
+			if exp > 0 {
+				p.PExpire(ctx, s.key(key), exp)
+			}
+			return nil
+		})
+		if err == nil {
+			s.recovered()
+			return int(count.Val()), nil
+		}
+		s.failed(err)
+	}
+
+	return s.fallback.Increment(key, by, exp)
+}
+
+// Count return the value of a counter, 0 when it does not exist
+func (s *Storage) Count(key string) (int, error) {
+	val, err := s.Get(key)
+	if err != nil || len(val) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(string(val))
+}
+
+// Reset delete every key under the prefix, the keys are deleted as they are
+// scanned
+func (s *Storage) Reset() error {
+	s.fallback.Reset()
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageResetTimeout)
+	defer cancel()
+
+	iter := s.redis.Scan(ctx, 0, s.key("*"), 100).Iterator()
+	keys := make([]string, 0, 100)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) < cap(keys) {
+			continue
+		}
+		if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+			return err
+		}
+		keys = keys[:0]
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.redis.Del(ctx, keys...).Err()
+}
+
+// Close release the in-memory values, the Redis client is closed by the cache
+// component
+func (s *Storage) Close() error {
+	return s.fallback.Close()
+}
+
+func (s *Storage) key(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + ":" + key
+}
+
+func (s *Storage) available() bool {
+	retryAt := s.retryAt.Load()
+	return retryAt == 0 || time.Now().UnixNano() >= retryAt
+}
+
+func (s *Storage) failed(err error) {
+	if s.retryAt.Swap(time.Now().Add(storageRetryInterval).UnixNano()) == 0 {
+		log.Printf("[WARN] Redis storage unavailable, middleware state is kept in memory by each process: %v", err)
+	}
+}
+
+func (s *Storage) recovered() {
+	if s.retryAt.Swap(0) != 0 {
+		log.Println("Redis storage available again")
+	}
+}
+
+// Memory -----------------------------------------------------------------------------
+
+// NewMemoryStorage return an empty in-memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{values: map[string]memoryValue{}}
+}
+
+func (m *MemoryStorage) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.values[key]
+	if !ok {
+		return nil, nil
+	}
+	if !value.expires.IsZero() && time.Now().After(value.expires) {
+		delete(m.values, key)
+		return nil, nil
+	}
+	return value.data, nil
+}
+
+func (m *MemoryStorage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value := memoryValue{data: append([]byte(nil), val...)}
+	if exp > 0 {
+		value.expires = time.Now().Add(exp)
+	}
+	m.values[key] = value
+	m.sweep()
+	return nil
+}
+
+// Increment add by to the counter and return its new value, the expiration is
+// renewed on every increment
+func (m *MemoryStorage) Increment(key string, by int, exp time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	if value, ok := m.values[key]; ok && (value.expires.IsZero() || now.Before(value.expires)) {
+		count, _ = strconv.Atoi(string(value.data))
+	}
+	count += by
+
+	value := memoryValue{data: []byte(strconv.Itoa(count))}
+	if exp > 0 {
+		value.expires = now.Add(exp)
+	}
+	m.values[key] = value
+	m.sweep()
+	return count, nil
+}
+
+// Count return the value of a counter, 0 when it does not exist
+func (m *MemoryStorage) Count(key string) (int, error) {
+	val, err := m.Get(key)
+	if err != nil || len(val) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(string(val))
+}
+
+func (m *MemoryStorage) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *MemoryStorage) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values = map[string]memoryValue{}
+	return nil
+}
+
+func (m *MemoryStorage) Close() error {
+	return m.Reset()
+}
+
+// sweep delete the expired values at most once a minute, keys that are never
+// read again (e.g. one per client IP) would pile up otherwise
+func (m *MemoryStorage) sweep() {
+	now := time.Now()
+	if now.Before(m.sweepAt) {
+		return
+	}
+	m.sweepAt = now.Add(time.Minute)
+	for key, value := range m.values {
+		if !value.expires.IsZero() && now.After(value.expires) {
+			delete(m.values, key)
+		}
+	}
+}
+
+var (
+	_ fiber.Storage = (*Storage)(nil)
+	_ fiber.Storage = (*MemoryStorage)(nil)
+)
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeRedis answer GET, SET and DEL, while it is down every connection is
+// closed before a reply
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	down   atomic.Bool
+}
+
+func newFakeRedis(t *testing.T) (*fakeRedis, *redis.Client) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{values: map[string]string{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), MaxRetries: -1})
+	t.Cleanup(func() {
+		client.Close()
+		ln.Close()
+	})
+	return f, client
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil || f.down.Load() {
+			return
+		}
+
+		f.mu.Lock()
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			if val, ok := f.values[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(val), val)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "SET":
+			f.values[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := f.values[key]; ok {
+					delete(f.values, key)
+					deleted++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", deleted)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) value(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	val, ok := f.values[key]
+	return val, ok
+}
+
+// readCommand read a RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestStorageKeys(t *testing.T) {
+	tests := []struct {
+		prefix string
+		name   string
+		want   string
+	}{
+		{prefix: "user-service", name: "storage", want: "user-service:storage:limiter:key"},
+		{prefix: "", name: "storage", want: "storage:limiter:key"},
+	}
+	for _, tt := range tests {
+		storage := NewStorage(nil, tt.prefix).Prefixed(tt.name).Prefixed("limiter")
+		if got := storage.key("key"); got != tt.want {
+			t.Errorf("key with prefix %q = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestStorageFallback(t *testing.T) {
+	fake, client := newFakeRedis(t)
+	storage := NewStorage(client, "test")
+
+	// Redis available, the values are set under the prefix
+	if err := storage.Set("a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if val, ok := fake.value("test:a"); !ok || val != "1" {
+		t.Fatalf("Redis test:a = %q, want 1", val)
+	}
+
+	tests := []struct {
+		name string
+		op   func() (string, error)
+		want string
+	}{
+		{name: "set", op: func() (string, error) { return "", storage.Set("b", []byte("2"), time.Minute) }},
+		{name: "get", op: func() (string, error) {
+			val, err := storage.Get("b")
+			return string(val), err
+		}, want: "2"},
+		{name: "increment", op: func() (string, error) {
+			count, err := storage.Increment("counter", 3, time.Minute)
+			return strconv.Itoa(count), err
+		}, want: "3"},
+		{name: "count", op: func() (string, error) {
+			count, err := storage.Count("counter")
+			return strconv.Itoa(count), err
+		}, want: "3"},
+		{name: "value set in Redis before the outage", op: func() (string, error) {
+			val, err := storage.Get("a")
+			return string(val), err
+		}, want: ""},
+	}
+
+	// Redis down, the values are kept in memory and Redis is not called
+	// again until the retry interval
+	fake.down.Store(true)
+	for _, tt := range tests {
+		started := time.Now()
+		got, err := tt.op()
+		if err != nil {
+			t.Errorf("%s: error %v, want the memory fallback", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+		}
+		if tt.name != "set" && time.Since(started) > storageTimeout {
+			t.Errorf("%s took %s, want Redis skipped during the retry interval", tt.name, time.Since(started))
+		}
+	}
+	if storage.available() {
+		t.Error("available() = true after a Redis failure")
+	}
+
+	// Redis back, it is used again once the retry interval elapsed
+	fake.down.Store(false)
+	storage.retryAt.Store(time.Now().Add(-time.Second).UnixNano())
+	if val, err := storage.Get("a"); err != nil || string(val) != "1" {
+		t.Errorf("Get(a) = %q, %v after the recovery, want 1 from Redis", val, err)
+	}
+	if !storage.available() {
+		t.Error("available() = false after the recovery")
+	}
+}
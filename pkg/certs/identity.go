@@ -0,0 +1,55 @@
+package certs
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"time"
+)
+
+// ClientIdentity describe a client certificate verified against the client CA
+// bundle
+type ClientIdentity struct {
+	Subject        string    `json:"subject"`
+	CommonName     string    `json:"common_name"`
+	Organization   []string  `json:"organization,omitempty"`
+	DNSNames       []string  `json:"dns_names,omitempty"`
+	EmailAddresses []string  `json:"email_addresses,omitempty"`
+	URIs           []string  `json:"uris,omitempty"`
+	SerialNumber   string    `json:"serial_number"`
+	Issuer         string    `json:"issuer"`
+	NotAfter       time.Time `json:"not_after"`
+	// SHA-256 fingerprint of the certificate, hex encoded
+	Fingerprint string `json:"fingerprint"`
+}
+
+// NewClientIdentity read the identity of a client certificate
+func NewClientIdentity(cert *x509.Certificate) *ClientIdentity {
+	fingerprint := sha256.Sum256(cert.Raw)
+	identity := &ClientIdentity{
+		Subject:        cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		Organization:   cert.Subject.Organization,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		SerialNumber:   cert.SerialNumber.String(),
+		Issuer:         cert.Issuer.String(),
+		NotAfter:       cert.NotAfter,
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+	}
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+
+	return identity
+}
+
+// VerifiedClientIdentity return the identity of the verified client certificate
+// of a connection, nil when the client did not present one
+func VerifiedClientIdentity(state *tls.ConnectionState) *ClientIdentity {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return NewClientIdentity(state.VerifiedChains[0][0])
+}
@@ -0,0 +1,124 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Reloader serve a certificate and a client CA bundle read from disk, the files
+// are read again by Reload so that rotated certificates are picked up without a
+// restart
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu        sync.Mutex
+	checksum  []byte
+	cert      atomic.Pointer[tls.Certificate]
+	clientCAs atomic.Pointer[x509.CertPool]
+}
+
+// NewReloader load the certificate, key and optional client CA bundle
+func NewReloader(certFile string, keyFile string, caFile string) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload read the files again when their content changed, it report whether
+// the certificate was replaced. The current certificate is kept when the new
+// files are invalid, e.g. when the key was not written yet.
+func (r *Reloader) Reload() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certPEM, err := os.ReadFile(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("tls: %w", err)
+	}
+	keyPEM, err := os.ReadFile(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("tls: %w", err)
+	}
+	var caPEM []byte
+	if r.caFile != "" {
+		if caPEM, err = os.ReadFile(r.caFile); err != nil {
+			return false, fmt.Errorf("tls: %w", err)
+		}
+	}
+
+	hash := sha256.New()
+	for _, content := range [][]byte{certPEM, keyPEM, caPEM} {
+		sum := sha256.Sum256(content)
+		hash.Write(sum[:])
+	}
+	checksum := hash.Sum(nil)
+	if bytes.Equal(checksum, r.checksum) {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("tls: cannot load key pair from %s and %s: %w", r.certFile, r.keyFile, err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return false, fmt.Errorf("tls: %s: %w", r.certFile, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.caFile != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return false, fmt.Errorf("tls: no certificate found in %s", r.caFile)
+		}
+	}
+
+	r.cert.Store(&cert)
+	r.clientCAs.Store(clientCAs)
+	r.checksum = checksum
+	return true, nil
+}
+
+// Certificate return the certificate currently served
+func (r *Reloader) Certificate() *x509.Certificate {
+	return r.cert.Load().Leaf
+}
+
+// KeyPair return the certificate and key currently served
+func (r *Reloader) KeyPair() tls.Certificate {
+	return *r.cert.Load()
+}
+
+// TLSConfig return a server config that use the latest certificate and client
+// CA bundle for every new connection
+func (r *Reloader) TLSConfig(clientAuth tls.ClientAuthType, minVersion uint16, nextProtos ...string) *tls.Config {
+	base := &tls.Config{
+		MinVersion: minVersion,
+		ClientAuth: clientAuth,
+		NextProtos: nextProtos,
+	}
+
+	config := base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		current := base.Clone()
+		current.Certificates = []tls.Certificate{r.KeyPair()}
+		current.ClientCAs = r.clientCAs.Load()
+		return current, nil
+	}
+
+	return config
+}
@@ -0,0 +1,147 @@
+package clientip
+
+import (
+	"net"
+	"strings"
+)
+
+// Proxy headers carrying the client IP
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// Resolver resolve the client IP of requests coming through trusted proxies
+type Resolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+// NewResolver return a resolver trusting the proxies in the trusted networks
+// to report the client IP in header
+func NewResolver(trusted []*net.IPNet, header string) *Resolver {
+	return &Resolver{trusted: trusted, header: header}
+}
+
+// Header return the header read by the resolver
+func (r *Resolver) Header() string {
+	return r.header
+}
+
+// Resolve return the client IP of a request from remote, the address of the
+// connection, and the values of the proxy header. The chain is walked from the
+// nearest hop and the first address that is not a trusted proxy is the
+// client, the hops further away could be forged by it. A malformed hop stops
+// the walk at the last trusted proxy.
+func (r *Resolver) Resolve(remote net.IP, values []string) net.IP {
+	if !r.Trusted(remote) {
+		return remote
+	}
+
+	var chain []string
+	switch r.header {
+	case HeaderForwarded:
+		chain = parseForwarded(values)
+	case HeaderXRealIP:
+		if len(values) > 0 {
+			chain = []string{strings.TrimSpace(values[len(values)-1])}
+		}
+	default:
+		chain = parseXForwardedFor(values)
+	}
+
+	client := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseIP(chain[i])
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !r.Trusted(ip) {
+			break
+		}
+	}
+	return client
+}
+
+// Trusted report whether ip is a trusted proxy
+func (r *Resolver) Trusted(ip net.IP) bool {
+	return Contains(r.trusted, ip)
+}
+
+// Contains report whether ip is in one of the networks
+func Contains(networks []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseXForwardedFor return the hops of "client, proxy1, proxy2" headers, the
+// header lines are concatenated in order
+func parseXForwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			chain = append(chain, strings.TrimSpace(hop))
+		}
+	}
+	return chain
+}
+
+// parseForwarded return the "for" parameter of every element of RFC 7239
+// headers, e.g. `for=192.0.2.43, for="[2001:db8:cafe::17]:4711";proto=https`.
+// Elements without "for" are kept empty so that they stop the walk.
+func parseForwarded(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			hop := ""
+			for _, pair := range splitQuoted(element, ';') {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hop = strings.Trim(val, `"`)
+				}
+			}
+			chain = append(chain, hop)
+		}
+	}
+	return chain
+}
+
+// splitQuoted split s on sep outside of quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseIP parse a hop address, with an optional port and IPv6 brackets:
+// 192.0.2.43, 192.0.2.43:80, 2001:db8::17, [2001:db8::17]:4711. Obfuscated
+// identifiers and "unknown" are not IPs.
+func parseIP(hop string) net.IP {
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	return net.ParseIP(strings.Trim(hop, "[]"))
+}
@@ -0,0 +1,287 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	// Component is a dependency of the service (database, cache, ...) whose
+	// lifecycle is managed by a Registry
+	Component interface {
+		// Name identify the component, other components depend on it by name
+		Name() string
+		Start(ctx context.Context) error
+		Stop(ctx context.Context) error
+		// Health report whether the component can serve requests
+		Health(ctx context.Context) error
+	}
+	// Registry start the registered components in dependency order and stop
+	// them in the reverse order
+	Registry struct {
+		mu           sync.Mutex
+		entries      []*entry
+		started      []*entry
+		startTimeout time.Duration
+		stopTimeout  time.Duration
+	}
+	// Error is a component that failed to start or stop
+	Error struct {
+		Component string
+		Op        string
+		Err       error
+	}
+	Option func(*entry)
+
+	entry struct {
+		component    Component
+		dependsOn    []string
+		startTimeout time.Duration
+		stopTimeout  time.Duration
+	}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("component %s: %s: %v", e.Component, e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// DependsOn start the component after the named ones, and stop it before them
+func DependsOn(names ...string) Option {
+	return func(e *entry) {
+		e.dependsOn = append(e.dependsOn, names...)
+	}
+}
+
+// WithStartTimeout override the registry start timeout for the component
+func WithStartTimeout(timeout time.Duration) Option {
+	return func(e *entry) {
+		e.startTimeout = timeout
+	}
+}
+
+// WithStopTimeout override the registry stop timeout for the component
+func WithStopTimeout(timeout time.Duration) Option {
+	return func(e *entry) {
+		e.stopTimeout = timeout
+	}
+}
+
+// NewRegistry is the constructor function for Registry, the timeouts apply to
+// every component that does not set its own
+func NewRegistry(startTimeout time.Duration, stopTimeout time.Duration) *Registry {
+	return &Registry{
+		startTimeout: startTimeout,
+		stopTimeout:  stopTimeout,
+	}
+}
+
+// Register add a component, it is started by Start
+func (r *Registry) Register(component Component, opts ...Option) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := &entry{
+		component:    component,
+		startTimeout: r.startTimeout,
+		stopTimeout:  r.stopTimeout,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	r.entries = append(r.entries, e)
+}
+
+// Get return the registered component with the name, nil when there is none
+func (r *Registry) Get(name string) Component {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.component.Name() == name {
+			return e.component
+		}
+	}
+	return nil
+}
+
+// Components return the registered components in start order
+func (r *Registry) Components() []Component {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered, err := r.order()
+	if err != nil {
+		ordered = r.entries
+	}
+	components := make([]Component, 0, len(ordered))
+	for _, e := range ordered {
+		components = append(components, e.component)
+	}
+	return components
+}
+
+// Start start every component after its dependencies. A component that fails
+// does not stop the others, only the components depending on it are skipped.
+// Every failure is reported in the returned error.
+func (r *Registry) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered, err := r.order()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	failed := map[string]bool{}
+	for _, e := range ordered {
+		name := e.component.Name()
+
+		var missing []string
+		for _, dependency := range e.dependsOn {
+			if failed[dependency] {
+				missing = append(missing, dependency)
+			}
+		}
+		if len(missing) > 0 {
+			failed[name] = true
+			errs = append(errs, &Error{Component: name, Op: "start", Err: fmt.Errorf("skipped, dependency %v did not start", missing)})
+			continue
+		}
+
+		started := time.Now()
+		if err := run(ctx, e.startTimeout, e.component.Start); err != nil {
+			failed[name] = true
+			errs = append(errs, &Error{Component: name, Op: "start", Err: err})
+			continue
+		}
+		r.started = append(r.started, e)
+		if !fiber.IsChild() {
+			log.Printf("Component %s started in %s", name, time.Since(started).Round(time.Millisecond))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Stop stop the started components in the reverse order, every component is
+// stopped even when another one fails
+func (r *Registry) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for i := len(r.started) - 1; i >= 0; i-- {
+		e := r.started[i]
+		if err := run(ctx, e.stopTimeout, e.component.Stop); err != nil {
+			errs = append(errs, &Error{Component: e.component.Name(), Op: "stop", Err: err})
+			continue
+		}
+		if !fiber.IsChild() {
+			log.Printf("Component %s stopped", e.component.Name())
+		}
+	}
+	r.started = nil
+
+	return errors.Join(errs...)
+}
+
+// Health check every started component, the result is keyed by component name
+// with a nil error for the healthy ones
+func (r *Registry) Health(ctx context.Context) map[string]error {
+	r.mu.Lock()
+	started := append([]*entry(nil), r.started...)
+	r.mu.Unlock()
+
+	health := make(map[string]error, len(started))
+	for _, e := range started {
+		health[e.component.Name()] = e.component.Health(ctx)
+	}
+	return health
+}
+
+// order sort the entries so that every component comes after its
+// dependencies, the registration order is kept otherwise
+func (r *Registry) order() ([]*entry, error) {
+	byName := map[string]*entry{}
+	for _, e := range r.entries {
+		name := e.component.Name()
+		if _, ok := byName[name]; ok {
+			return nil, fmt.Errorf("component %s: registered twice", name)
+		}
+		byName[name] = e
+	}
+	for _, e := range r.entries {
+		for _, dependency := range e.dependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return nil, fmt.Errorf("component %s: depends on unknown component %s", e.component.Name(), dependency)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	ordered := make([]*entry, 0, len(r.entries))
+	var visit func(e *entry, path []string) error
+	visit = func(e *entry, path []string) error {
+		name := e.component.Name()
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("component %s: dependency cycle %v", name, append(path, name))
+		}
+		state[name] = visiting
+		for _, dependency := range e.dependsOn {
+			if err := visit(byName[dependency], append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, e)
+		return nil
+	}
+	for _, e := range r.entries {
+		if err := visit(e, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// run call fn with a deadline, it returns when the deadline is exceeded even if
+// fn ignores its context
+func run(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w after %s", ctx.Err(), timeout)
+	}
+}
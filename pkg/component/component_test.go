@@ -0,0 +1,189 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fake record its start and stop in a shared log
+type fake struct {
+	name     string
+	log      *[]string
+	startErr error
+	stopErr  error
+	block    bool
+}
+
+func (f *fake) Name() string {
+	return f.name
+}
+
+func (f *fake) Start(ctx context.Context) error {
+	if f.block {
+		<-make(chan bool)
+	}
+	*f.log = append(*f.log, "start "+f.name)
+	return f.startErr
+}
+
+func (f *fake) Stop(ctx context.Context) error {
+	*f.log = append(*f.log, "stop "+f.name)
+	return f.stopErr
+}
+
+func (f *fake) Health(ctx context.Context) error {
+	return nil
+}
+
+func TestRegistryOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		register   func(r *Registry, log *[]string)
+		wantStart  []string
+		wantStop   []string
+		wantErrors []string
+	}{
+		{
+			name: "registration order",
+			register: func(r *Registry, log *[]string) {
+				r.Register(&fake{name: "a", log: log})
+				r.Register(&fake{name: "b", log: log})
+			},
+			wantStart: []string{"start a", "start b"},
+			wantStop:  []string{"stop b", "stop a"},
+		},
+		{
+			name: "dependencies first",
+			register: func(r *Registry, log *[]string) {
+				r.Register(&fake{name: "api", log: log}, DependsOn("cache", "database"))
+				r.Register(&fake{name: "cache", log: log}, DependsOn("database"))
+				r.Register(&fake{name: "database", log: log})
+			},
+			wantStart: []string{"start database", "start cache", "start api"},
+			wantStop:  []string{"stop api", "stop cache", "stop database"},
+		},
+		{
+			name: "dependents of a failed component skipped",
+			register: func(r *Registry, log *[]string) {
+				r.Register(&fake{name: "database", log: log, startErr: errors.New("refused")})
+				r.Register(&fake{name: "cache", log: log})
+				r.Register(&fake{name: "repository", log: log}, DependsOn("database"))
+				r.Register(&fake{name: "api", log: log}, DependsOn("repository"))
+			},
+			wantStart: []string{"start database", "start cache"},
+			wantStop:  []string{"stop cache"},
+			wantErrors: []string{
+				"component database: start: refused",
+				"component repository: start: skipped, dependency [database] did not start",
+				"component api: start: skipped, dependency [repository] did not start",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			r := NewRegistry(time.Second, time.Second)
+			tt.register(r, &log)
+
+			err := r.Start(context.Background())
+			for _, want := range tt.wantErrors {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("Start() = %v, want %q", err, want)
+				}
+			}
+			if len(tt.wantErrors) == 0 && err != nil {
+				t.Errorf("Start() = %v", err)
+			}
+			if !reflect.DeepEqual(log, tt.wantStart) {
+				t.Errorf("start order = %v, want %v", log, tt.wantStart)
+			}
+
+			log = nil
+			if err := r.Stop(context.Background()); err != nil {
+				t.Errorf("Stop() = %v", err)
+			}
+			if !reflect.DeepEqual(log, tt.wantStop) {
+				t.Errorf("stop order = %v, want %v", log, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestRegistryInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Registry, log *[]string)
+		wantErr  string
+	}{
+		{
+			name: "cycle",
+			register: func(r *Registry, log *[]string) {
+				r.Register(&fake{name: "a", log: log}, DependsOn("c"))
+				r.Register(&fake{name: "b", log: log}, DependsOn("a"))
+				r.Register(&fake{name: "c", log: log}, DependsOn("b"))
+			},
+			wantErr: "component a: dependency cycle [a c b a]",
+		},
+		{
+			name: "self dependency",
+			register: func(r *Registry, log *[]string) {
+				r.Register(&fake{name: "a", log: log}, DependsOn("a"))
+			},
+			wantErr: "component a: dependency cycle [a a]",
+		},
+		{
+			name: "unknown dependency",
+			register: func(r *Registry, log *[]string) {
+				r.Register(&fake{name: "a", log: log}, DependsOn("b"))
+			},
+			wantErr: "component a: depends on unknown component b",
+		},
+		{
+			name: "registered twice",
+			register: func(r *Registry, log *[]string) {
+				r.Register(&fake{name: "a", log: log})
+				r.Register(&fake{name: "a", log: log})
+			},
+			wantErr: "component a: registered twice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			r := NewRegistry(time.Second, time.Second)
+			tt.register(r, &log)
+
+			if err := r.Start(context.Background()); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Start() = %v, want %q", err, tt.wantErr)
+			}
+			if len(log) > 0 {
+				t.Errorf("started %v, want none", log)
+			}
+		})
+	}
+}
+
+func TestRegistryTimeouts(t *testing.T) {
+	var log []string
+	r := NewRegistry(time.Second, time.Second)
+	r.Register(&fake{name: "stuck", log: &log, block: true}, WithStartTimeout(50*time.Millisecond))
+	r.Register(&fake{name: "cache", log: &log, stopErr: errors.New("closed")})
+
+	err := r.Start(context.Background())
+	var componentErr *Error
+	if !errors.As(err, &componentErr) || componentErr.Component != "stuck" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Start() = %v, want the stuck component to time out", err)
+	}
+
+	// The components that started are stopped, their errors are reported
+	if err := r.Stop(context.Background()); err == nil || err.Error() != "component cache: stop: closed" {
+		t.Errorf("Stop() = %v, want the cache stop error", err)
+	}
+	if got := r.Health(context.Background()); len(got) != 0 {
+		t.Errorf("Health() = %v after Stop, want no component", got)
+	}
+}
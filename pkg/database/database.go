@@ -6,6 +6,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// MaxLimit is the largest page size, larger limits are lowered to it
+const MaxLimit = 100
+
 type Pagination struct {
 	Limit      int         `json:"limit" query:"limit"`
 	Page       int         `json:"page" query:"page"`
@@ -20,15 +23,18 @@ func (p *Pagination) GetOffset() int {
 }
 
 func (p *Pagination) GetLimit() int {
-	if p.Limit == 0 {
+	if p.Limit <= 0 {
 		p.Limit = 10
 	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
 
 	return p.Limit
 }
 
 func (p *Pagination) GetPage() int {
-	if p.Page == 0 {
+	if p.Page <= 0 {
 		p.Page = 1
 	}
 
@@ -58,7 +64,7 @@ func Paginate(value interface{}, pagination *Pagination, db *gorm.DB) func(db *g
 	db.Model(value).Count(&totalRows)
 
 	pagination.TotalRows = totalRows
-	totalPages := int(math.Ceil(float64(totalRows) / float64(pagination.Limit)))
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pagination.GetLimit())))
 	pagination.TotalPages = totalPages
 
 	return func(db *gorm.DB) *gorm.DB {
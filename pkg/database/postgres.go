@@ -1,8 +1,10 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"log"
-	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils/color"
@@ -12,7 +14,86 @@ import (
 	"gorm.io/plugin/dbresolver"
 )
 
-func Initialize(config *config.Config) *gorm.DB {
+// ComponentName is the name of the database component
+const ComponentName = "database"
+
+// Component manage the database connection pool
+type Component struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+// NewComponent is the constructor function for the database component, the
+// connection is opened by Start
+func NewComponent(config *config.Config) *Component {
+	return &Component{config: config}
+}
+
+func (c *Component) Name() string {
+	return ComponentName
+}
+
+func (c *Component) Start(ctx context.Context) error {
+	db, err := Initialize(c.config)
+	if err != nil {
+		return err
+	}
+	c.db = db
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (c *Component) Stop(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (c *Component) Health(ctx context.Context) error {
+	_, err := c.Check(ctx)
+	return err
+}
+
+// Check ping the database, the details are the connection pool statistics
+func (c *Component) Check(ctx context.Context) (any, error) {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := sqlDB.Stats()
+	details := fiber.Map{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration":        stats.WaitDuration.String(),
+	}
+	return details, sqlDB.PingContext(ctx)
+}
+
+// DB return the connection pool, nil until the component is started
+func (c *Component) DB() *gorm.DB {
+	return c.db
+}
+
+// Stats return the connection pool statistics
+func (c *Component) Stats() sql.DBStats {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+func Initialize(config *config.Config) (*gorm.DB, error) {
 	if !fiber.IsChild() {
 		log.Println("Database connecting...")
 	}
@@ -20,32 +101,34 @@ func Initialize(config *config.Config) *gorm.DB {
 	dbConn, err := gorm.Open(
 		postgres.New(
 			postgres.Config{
-				DSN: config.Database.DatabaseDSN,
+				DSN: config.Database.DatabaseDSN.Value(),
 			},
 		),
 		&gorm.Config{},
 	)
-	dbConn.Use(
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to database: %w", err)
+	}
+
+	err = dbConn.Use(
 		dbresolver.Register(dbresolver.Config{
 			Sources:           []gorm.Dialector{},
 			Replicas:          []gorm.Dialector{},
 			Policy:            nil,
 			TraceResolverMode: false,
 		}).
-			SetConnMaxIdleTime(time.Hour).
-			SetConnMaxLifetime(24 * time.Hour).
+			SetConnMaxIdleTime(config.Database.DatabaseConnMaxIdleTime).
+			SetConnMaxLifetime(config.Database.DatabaseConnMaxLifetime).
 			SetMaxIdleConns(config.Database.DatabaseMaxIdleConns).
 			SetMaxOpenConns(config.Database.DatabaseMaxOpenConns),
 	)
-
 	if err != nil {
-		log.Printf("Cannot connect to database")
-		log.Fatal("DatabaseError:", err)
+		return nil, err
 	}
 
 	if !fiber.IsChild() {
 		log.Println("Database connected", color.Format(color.GREEN, "successfully!"))
 	}
 
-	return dbConn
+	return dbConn, nil
 }
@@ -0,0 +1,54 @@
+package exceptions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/getsentry/sentry-go"
+)
+
+// ComponentName is the name of the Sentry component
+const ComponentName = "sentry"
+
+// Default time given to Sentry to deliver the buffered events on stop
+const flushTimeout = 2 * time.Second
+
+// Component manage the Sentry client, it does nothing when no DSN is configured
+type Component struct {
+	config *config.Config
+}
+
+// NewComponent is the constructor function for the Sentry component
+func NewComponent(config *config.Config) *Component {
+	return &Component{config: config}
+}
+
+func (c *Component) Name() string {
+	return ComponentName
+}
+
+func (c *Component) Start(ctx context.Context) error {
+	return SentryInitialize(c.config)
+}
+
+// Stop flush the buffered events before the program terminates
+func (c *Component) Stop(ctx context.Context) error {
+	if c.config.Sentry.SentryDSN == "" {
+		return nil
+	}
+
+	timeout := flushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if !sentry.Flush(timeout) {
+		return errors.New("buffered events were not delivered before the deadline")
+	}
+	return nil
+}
+
+func (c *Component) Health(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package exceptions
+
+import (
+	"context"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/requestid"
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Status codes reported to Sentry, the other ones are client errors
+var reportedCodes = map[codes.Code]bool{
+	codes.Unknown:  true,
+	codes.Internal: true,
+	codes.DataLoss: true,
+}
+
+// SentryUnaryServerInterceptor recover panics and report server errors of
+// unary gRPC calls to Sentry
+func SentryUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetTag("grpc.method", info.FullMethod)
+		hub.Scope().SetTag("request_id", requestid.FromContext(ctx))
+		ctx = sentry.SetHubOnContext(ctx, hub)
+
+		defer func() {
+			if r := recover(); r != nil {
+				hub.RecoverWithContext(ctx, r)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		if err != nil && reportedCodes[status.Code(err)] {
+			hub.CaptureException(err)
+		}
+
+		return resp, err
+	}
+}
+
+// SentryStreamServerInterceptor recover panics of streaming gRPC calls and
+// report them to Sentry
+func SentryStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetTag("grpc.method", info.FullMethod)
+		hub.Scope().SetTag("request_id", requestid.FromContext(stream.Context()))
+
+		defer func() {
+			if r := recover(); r != nil {
+				hub.RecoverWithContext(stream.Context(), r)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return handler(srv, stream)
+	}
+}
@@ -1,7 +1,10 @@
 package exceptions
 
 import (
+	"fmt"
 	"log"
+	"math"
+	"sync/atomic"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils/color"
@@ -9,16 +12,23 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func SentryInitialize(config *config.Config) {
+// Traces sample rate (float64 bits), it can be changed on a running server
+var tracesSampleRate atomic.Uint64
+
+func SentryInitialize(config *config.Config) error {
+	SetTracesSampleRate(config.Sentry.SentryTracesSampleRate)
+
 	err := sentry.Init(sentry.ClientOptions{
-		Dsn:              config.Sentry.SentryDSN,
-		EnableTracing:    config.Sentry.SentryEnableTracing,
-		TracesSampleRate: config.Sentry.SentryTracesSampleRate,
+		Dsn:           config.Sentry.SentryDSN.Value(),
+		EnableTracing: config.Sentry.SentryEnableTracing,
+		TracesSampler: func(ctx sentry.SamplingContext) float64 {
+			return math.Float64frombits(tracesSampleRate.Load())
+		},
 	})
 
 	// If there is an error, do not continue.
 	if err != nil {
-		log.Fatalf("sentry.Init: %s", err)
+		return fmt.Errorf("sentry.Init: %w", err)
 	}
 
 	// Check if Sentry DSN is already set
@@ -31,4 +41,11 @@ func SentryInitialize(config *config.Config) {
 			}
 		}
 	}
+
+	return nil
+}
+
+// SetTracesSampleRate change the traces sample rate of the initialized client
+func SetTracesSampleRate(rate float64) {
+	tracesSampleRate.Store(math.Float64bits(rate))
 }
@@ -0,0 +1,244 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded is reported when only optional checks fail, the probe
+	// still succeeds
+	StatusDegraded Status = "degraded"
+	StatusFailing  Status = "failing"
+)
+
+type (
+	Status string
+	// Checker check a single dependency, details are added to the report
+	// (pool statistics, last export, ...) and may be nil
+	Checker interface {
+		Name() string
+		Check(ctx context.Context) (details any, err error)
+	}
+	// Probe run its checkers and cache the report, so that aggressive polling
+	// does not overload the dependencies
+	Probe struct {
+		name     string
+		ttl      time.Duration
+		timeout  time.Duration
+		latched  bool
+		mu       sync.Mutex
+		checkers []*entry
+		report   *Report
+		expires  time.Time
+	}
+	// Report is the result of a probe run
+	Report struct {
+		Probe     string    `json:"probe"`
+		Status    Status    `json:"status"`
+		CheckedAt time.Time `json:"checked_at"`
+		Checks    []*Check  `json:"checks"`
+	}
+	// Check is the result of a single checker
+	Check struct {
+		Name      string  `json:"name"`
+		Status    Status  `json:"status"`
+		Optional  bool    `json:"optional,omitempty"`
+		LatencyMs float64 `json:"latency_ms"`
+		Error     string  `json:"error,omitempty"`
+		Details   any     `json:"details,omitempty"`
+	}
+	ProbeOption func(*Probe)
+	CheckOption func(*entry)
+
+	entry struct {
+		checker  Checker
+		optional bool
+	}
+	checkerFunc struct {
+		name  string
+		check func(ctx context.Context) error
+	}
+)
+
+// NewChecker return a checker without details
+func NewChecker(name string, check func(ctx context.Context) error) Checker {
+	return checkerFunc{name: name, check: check}
+}
+
+func (c checkerFunc) Name() string {
+	return c.name
+}
+
+func (c checkerFunc) Check(ctx context.Context) (any, error) {
+	return nil, c.check(ctx)
+}
+
+// WithCacheTTL set how long a report is served before the checks run again
+func WithCacheTTL(ttl time.Duration) ProbeOption {
+	return func(p *Probe) {
+		p.ttl = ttl
+	}
+}
+
+// WithTimeout set the deadline of every check
+func WithTimeout(timeout time.Duration) ProbeOption {
+	return func(p *Probe) {
+		p.timeout = timeout
+	}
+}
+
+// Latched keep the probe succeeding once it succeeded, the checks do not run
+// anymore (startup probe)
+func Latched() ProbeOption {
+	return func(p *Probe) {
+		p.latched = true
+	}
+}
+
+// Optional report a failing check without failing the probe
+func Optional() CheckOption {
+	return func(e *entry) {
+		e.optional = true
+	}
+}
+
+// NewProbe is the constructor function for Probe
+func NewProbe(name string, opts ...ProbeOption) *Probe {
+	p := &Probe{name: name}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Register add a checker to the probe
+func (p *Probe) Register(checker Checker, opts ...CheckOption) {
+	e := &entry{checker: checker}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkers = append(p.checkers, e)
+	p.report = nil
+}
+
+// Reset drop the cached report, the next run check every dependency again
+func (p *Probe) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.report = nil
+}
+
+// Run return the cached report, or run every checker in parallel when it
+// expired. Concurrent callers wait for the same run.
+func (p *Probe) Run(ctx context.Context) *Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.report != nil {
+		if p.latched && p.report.Status != StatusFailing {
+			return p.report
+		}
+		if time.Now().Before(p.expires) {
+			return p.report
+		}
+	}
+
+	report := &Report{
+		Probe:     p.name,
+		Status:    StatusOK,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make([]*Check, len(p.checkers)),
+	}
+
+	// The report is shared with the other callers, a canceled request must not
+	// fail the checks
+	ctx = context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	for i, e := range p.checkers {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			report.Checks[i] = p.check(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status == StatusOK {
+			continue
+		}
+		if !check.Optional {
+			report.Status = StatusFailing
+			break
+		}
+		report.Status = StatusDegraded
+	}
+
+	p.report = report
+	p.expires = time.Now().Add(p.ttl)
+	return report
+}
+
+// Handler serve the report as JSON, with 503 when the probe fails
+func (p *Probe) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := p.Run(c.UserContext())
+
+		status := fiber.StatusOK
+		if report.Status == StatusFailing {
+			status = fiber.StatusServiceUnavailable
+		}
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.Status(status).JSON(report)
+	}
+}
+
+func (p *Probe) check(ctx context.Context, e *entry) *Check {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		details any
+		err     error
+	}
+	done := make(chan result, 1)
+	started := time.Now()
+	go func() {
+		details, err := e.checker.Check(ctx)
+		done <- result{details, err}
+	}()
+
+	// Checkers ignoring their context do not hold the probe past the deadline
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		r.err = fmt.Errorf("%w after %s", ctx.Err(), p.timeout)
+	}
+
+	check := &Check{
+		Name:      e.checker.Name(),
+		Status:    StatusOK,
+		Optional:  e.optional,
+		LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+		Details:   r.details,
+	}
+	if r.err != nil {
+		check.Status = StatusFailing
+		check.Error = r.err.Error()
+	}
+	return check
+}
@@ -0,0 +1,209 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// counter count the runs of a check, it fails while err is set
+type counter struct {
+	runs atomic.Int32
+	mu   sync.Mutex
+	err  error
+}
+
+func (c *counter) check(ctx context.Context) error {
+	c.runs.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *counter) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func TestProbeCache(t *testing.T) {
+	c := &counter{}
+	p := NewProbe("readiness", WithCacheTTL(100*time.Millisecond))
+	p.Register(NewChecker("database", c.check))
+
+	first := p.Run(context.Background())
+	if second := p.Run(context.Background()); second != first || c.runs.Load() != 1 {
+		t.Errorf("checks ran %d times within the TTL, want 1", c.runs.Load())
+	}
+
+	// A failure is only seen once the report expired
+	c.fail(errors.New("refused"))
+	if report := p.Run(context.Background()); report.Status != StatusOK {
+		t.Errorf("status = %s within the TTL, want the cached %s", report.Status, StatusOK)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if report := p.Run(context.Background()); report.Status != StatusFailing || c.runs.Load() != 2 {
+		t.Errorf("status = %s after %d runs, want %s after 2", report.Status, c.runs.Load(), StatusFailing)
+	}
+
+	// Reset drop the report before it expired
+	c.fail(nil)
+	p.Reset()
+	if report := p.Run(context.Background()); report.Status != StatusOK || c.runs.Load() != 3 {
+		t.Errorf("status = %s after %d runs, want %s after 3", report.Status, c.runs.Load(), StatusOK)
+	}
+}
+
+func TestProbeConcurrentRuns(t *testing.T) {
+	c := &counter{}
+	p := NewProbe("readiness", WithCacheTTL(time.Minute))
+	p.Register(NewChecker("slow", func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return c.check(ctx)
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Run(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if runs := c.runs.Load(); runs != 1 {
+		t.Errorf("checks ran %d times for concurrent callers, want 1", runs)
+	}
+}
+
+func TestProbeLatched(t *testing.T) {
+	c := &counter{}
+	c.fail(errors.New("migrating"))
+	p := NewProbe("startup", Latched())
+	p.Register(NewChecker("database", c.check))
+
+	// A failing report is not latched, the checks run again
+	if report := p.Run(context.Background()); report.Status != StatusFailing {
+		t.Fatalf("status = %s, want %s", report.Status, StatusFailing)
+	}
+	c.fail(nil)
+	if report := p.Run(context.Background()); report.Status != StatusOK {
+		t.Fatalf("status = %s, want %s", report.Status, StatusOK)
+	}
+
+	// Once succeeded, the checks never run again
+	c.fail(errors.New("refused"))
+	for i := 0; i < 3; i++ {
+		if report := p.Run(context.Background()); report.Status != StatusOK {
+			t.Errorf("status = %s after the latch, want %s", report.Status, StatusOK)
+		}
+	}
+	if runs := c.runs.Load(); runs != 2 {
+		t.Errorf("checks ran %d times, want 2", runs)
+	}
+}
+
+func TestProbeStatus(t *testing.T) {
+	refused := func(ctx context.Context) error { return errors.New("refused") }
+	ok := func(ctx context.Context) error { return nil }
+	stuck := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		register   func(p *Probe)
+		wantStatus Status
+		wantHTTP   int
+		wantError  string
+	}{
+		{
+			name:       "no checks",
+			register:   func(p *Probe) {},
+			wantStatus: StatusOK,
+			wantHTTP:   fiber.StatusOK,
+		},
+		{
+			name: "required check failing",
+			register: func(p *Probe) {
+				p.Register(NewChecker("database", refused))
+				p.Register(NewChecker("cache", ok))
+			},
+			wantStatus: StatusFailing,
+			wantHTTP:   fiber.StatusServiceUnavailable,
+			wantError:  "refused",
+		},
+		{
+			name: "optional check failing",
+			register: func(p *Probe) {
+				p.Register(NewChecker("database", ok))
+				p.Register(NewChecker("exporter", refused), Optional())
+			},
+			wantStatus: StatusDegraded,
+			wantHTTP:   fiber.StatusOK,
+			wantError:  "refused",
+		},
+		{
+			name: "optional and required checks failing",
+			register: func(p *Probe) {
+				p.Register(NewChecker("exporter", refused), Optional())
+				p.Register(NewChecker("database", refused))
+			},
+			wantStatus: StatusFailing,
+			wantHTTP:   fiber.StatusServiceUnavailable,
+			wantError:  "refused",
+		},
+		{
+			name: "check past the timeout",
+			register: func(p *Probe) {
+				p.Register(NewChecker("database", stuck))
+			},
+			wantStatus: StatusFailing,
+			wantHTTP:   fiber.StatusServiceUnavailable,
+			wantError:  "context deadline exceeded after 50ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProbe("readiness", WithTimeout(50*time.Millisecond))
+			tt.register(p)
+
+			started := time.Now()
+			report := p.Run(context.Background())
+			if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+				t.Errorf("probe took %s, want the check timeout to apply", elapsed)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			if tt.wantError != "" {
+				var errs []string
+				for _, check := range report.Checks {
+					errs = append(errs, check.Error)
+				}
+				if !strings.Contains(strings.Join(errs, ","), tt.wantError) {
+					t.Errorf("check errors = %v, want %q", errs, tt.wantError)
+				}
+			}
+
+			app := fiber.New()
+			app.Get("/readyz", p.Handler())
+			resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantHTTP {
+				t.Errorf("HTTP status = %d, want %d", resp.StatusCode, tt.wantHTTP)
+			}
+		})
+	}
+}
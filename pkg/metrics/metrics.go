@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are registered on the Prometheus default registry, it also
+// collects the Go runtime and process metrics
+var (
+	HttpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	RateLimitBypasses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_bypasses_total",
+		Help: "Number of requests that skipped the rate limiter, by mechanism (signed, cidr).",
+	}, []string{"mechanism"})
+
+	DeprecatedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_deprecated_requests_total",
+		Help: "Number of requests to deprecated routes, by method and route.",
+	}, []string{"method", "route"})
+)
+
+// RegisterDatabase expose the connection pool stats of db
+func RegisterDatabase(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// HandlerFor serve the metrics of the gatherer in the Prometheus text format,
+// the ones that could be gathered are served when a source fails
+func HandlerFor(gatherer prometheus.Gatherer) fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}),
+	))
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// processScrapeTimeout bound the scrape of the metrics of a process
+const processScrapeTimeout = 2 * time.Second
+
+// processGatherer gather the metrics served by other processes on unix
+// sockets, e.g. the prefork children
+type processGatherer struct {
+	sockets func() map[int]string
+}
+
+// ServeUnix serve the metrics of this process on a unix socket until the
+// returned listener is closed, another process gathers them with
+// ProcessGatherer
+func ServeUnix(path string) (net.Listener, error) {
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	go http.Serve(listener, promhttp.Handler())
+	return listener, nil
+}
+
+// ProcessGatherer gather the metrics the processes serve with ServeUnix,
+// sockets return their socket by pid. The series of a process are labelled
+// with its pid, the ones of the processes that cannot be scraped are missing
+// and reported in the error.
+func ProcessGatherer(sockets func() map[int]string) prometheus.Gatherer {
+	return &processGatherer{sockets: sockets}
+}
+
+func (g *processGatherer) Gather() ([]*dto.MetricFamily, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		families = map[string]*dto.MetricFamily{}
+		errs     prometheus.MultiError
+	)
+	for pid, path := range g.sockets() {
+		wg.Add(1)
+		go func(pid int, path string) {
+			defer wg.Done()
+			processFamilies, err := scrapeUnix(path)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("pid %d: %w", pid, err))
+				return
+			}
+			for name, family := range processFamilies {
+				for _, metric := range family.Metric {
+					metric.Label = append(metric.Label, &dto.LabelPair{Name: proto.String("pid"), Value: proto.String(strconv.Itoa(pid))})
+					sort.Slice(metric.Label, func(i, j int) bool {
+						return metric.Label[i].GetName() < metric.Label[j].GetName()
+					})
+				}
+				if merged, ok := families[name]; ok {
+					merged.Metric = append(merged.Metric, family.Metric...)
+				} else {
+					families[name] = family
+				}
+			}
+		}(pid, path)
+	}
+	wg.Wait()
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		result = append(result, family)
+	}
+	return result, errs.MaybeUnwrap()
+}
+
+// scrapeUnix read the metrics served on a unix socket
+func scrapeUnix(path string) (map[string]*dto.MetricFamily, error) {
+	client := &http.Client{
+		Timeout: processScrapeTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://process/metrics")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
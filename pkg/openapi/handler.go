@@ -0,0 +1,55 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed swagger_ui.html
+var swaggerUIPage string
+
+var swaggerUITemplate = template.Must(template.New("swagger_ui").Parse(swaggerUIPage))
+
+// Handler serve the document of the registry as JSON. It is built on the
+// first request, once every route is registered.
+func Handler(registry *Registry) fiber.Handler {
+	var (
+		once     sync.Once
+		document []byte
+		err      error
+	)
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			document, err = json.Marshal(registry.Document())
+		})
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(document)
+	}
+}
+
+// SwaggerUI serve the Swagger UI page of the document at specURL, the page is
+// embedded and loads the swagger-ui-dist assets from assetsURL, e.g.
+// https://unpkg.com/swagger-ui-dist@5
+func SwaggerUI(title string, specURL string, assetsURL string) fiber.Handler {
+	var page bytes.Buffer
+	err := swaggerUITemplate.Execute(&page, map[string]string{
+		"Title":   title,
+		"SpecURL": specURL,
+		"Assets":  assetsURL,
+	})
+	return func(c *fiber.Ctx) error {
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Send(page.Bytes())
+	}
+}
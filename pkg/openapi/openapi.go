@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Version is the OpenAPI specification version of the documents
+const Version = "3.1.0"
+
+// MIMEApplicationJSON is the media type of the request and response bodies
+const MIMEApplicationJSON = "application/json"
+
+type (
+	// Document is an OpenAPI document
+	Document struct {
+		OpenAPI    string              `json:"openapi"`
+		Info       Info                `json:"info"`
+		Paths      map[string]PathItem `json:"paths"`
+		Components Components          `json:"components"`
+	}
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+	// PathItem are the operations of a path, keyed by lowercase method
+	PathItem   map[string]*Operation
+	Components struct {
+		Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+	// SecurityScheme is how the clients authenticate, e.g. an HTTP bearer
+	// token
+	SecurityScheme struct {
+		Type         string `json:"type"`
+		Description  string `json:"description,omitempty"`
+		Scheme       string `json:"scheme,omitempty"`
+		BearerFormat string `json:"bearerFormat,omitempty"`
+	}
+	// SecurityRequirement are the security schemes an operation requires,
+	// with the scopes or roles needed for each
+	SecurityRequirement map[string][]string
+	Operation           struct {
+		OperationID string                `json:"operationId,omitempty"`
+		Summary     string                `json:"summary,omitempty"`
+		Description string                `json:"description,omitempty"`
+		Tags        []string              `json:"tags,omitempty"`
+		Parameters  []*Parameter          `json:"parameters,omitempty"`
+		RequestBody *RequestBody          `json:"requestBody,omitempty"`
+		Responses   map[string]*Response  `json:"responses"`
+		Deprecated  bool                  `json:"deprecated,omitempty"`
+		Security    []SecurityRequirement `json:"security,omitempty"`
+	}
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+	RequestBody struct {
+		Description string               `json:"description,omitempty"`
+		Required    bool                 `json:"required,omitempty"`
+		Content     map[string]MediaType `json:"content"`
+	}
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	// Registry collect the routes of a server, the document is built from
+	// them and from their annotations
+	Registry struct {
+		Info            Info
+		mu              sync.Mutex
+		routes          []*Route
+		errors          *response
+		securitySchemes map[string]*SecurityScheme
+		validatorOnce   sync.Once
+		validator       *Validator
+	}
+)
+
+// NewRegistry is the constructor function for Registry
+func NewRegistry(info Info) *Registry {
+	return &Registry{Info: info}
+}
+
+// Add register a route, the returned route is annotated to document it
+func (r *Registry) Add(method string, path string) *Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route := &Route{method: strings.ToLower(method), path: path}
+	r.routes = append(r.routes, route)
+	return route
+}
+
+// Errors document the body of the error responses of every route, as the
+// "default" response
+func (r *Registry) Errors(value interface{}, description string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors = &response{value: value, description: description}
+}
+
+// SecurityScheme document a security scheme, the routes refer to it by name
+// (see Route.Security)
+func (r *Registry) SecurityScheme(name string, scheme *SecurityScheme) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.securitySchemes == nil {
+		r.securitySchemes = map[string]*SecurityScheme{}
+	}
+	r.securitySchemes[name] = scheme
+}
+
+// Validator return the validator of the document, it is built on the first
+// call so the routes must be registered by then
+func (r *Registry) Validator() *Validator {
+	r.validatorOnce.Do(func() {
+		r.validator = NewValidator(r.Document())
+	})
+	return r.validator
+}
+
+// Document build the document of the registered routes, the schemas of the
+// Go types are put in the components and referenced
+func (r *Registry) Document() *Document {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g := newGenerator()
+	document := &Document{
+		OpenAPI: Version,
+		Info:    r.Info,
+		Paths:   map[string]PathItem{},
+	}
+	for _, route := range r.routes {
+		if route.hidden {
+			continue
+		}
+		path, params := convertPath(route.path)
+		item, ok := document.Paths[path]
+		if !ok {
+			item = PathItem{}
+			document.Paths[path] = item
+		}
+		item[route.method] = route.operation(g, params, r.errors)
+	}
+	document.Components.Schemas = g.schemas
+	document.Components.SecuritySchemes = r.securitySchemes
+	return document
+}
+
+// operation build the operation of the route, the path parameters that are
+// not annotated are documented as strings
+func (r *Route) operation(g *generator, pathParams []string, errors *response) *Operation {
+	operation := r.info
+	operation.Tags = append([]string(nil), r.info.Tags...)
+	operation.Security = append([]SecurityRequirement(nil), r.info.Security...)
+	operation.Responses = map[string]*Response{}
+
+	annotated := map[string]bool{}
+	for _, p := range r.params {
+		if p.in == "path" {
+			annotated[p.name] = true
+		}
+	}
+	for _, name := range pathParams {
+		if !annotated[name] {
+			operation.Parameters = append(operation.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	for _, p := range r.params {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:        p.name,
+			In:          p.in,
+			Description: p.description,
+			Required:    p.required,
+			Schema:      g.schema(p.value),
+		})
+	}
+
+	if r.body != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{MIMEApplicationJSON: {Schema: g.schema(r.body)}},
+		}
+	}
+
+	for _, res := range r.responses {
+		operation.Responses[strconv.Itoa(res.status)] = res.response(g)
+	}
+	if errors != nil {
+		operation.Responses["default"] = errors.response(g)
+	}
+	if len(operation.Responses) == 0 {
+		operation.Responses[strconv.Itoa(http.StatusOK)] = &Response{Description: http.StatusText(http.StatusOK)}
+	}
+	return &operation
+}
+
+func (r *response) response(g *generator) *Response {
+	description := r.description
+	if description == "" {
+		description = http.StatusText(r.status)
+	}
+	res := &Response{Description: description}
+	if r.value != nil {
+		res.Content = map[string]MediaType{MIMEApplicationJSON: {Schema: g.schema(r.value)}}
+	}
+	return res
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_-]+)(<[^>]*>)?\??|[*+]`)
+
+// convertPath convert a Fiber route path to an OpenAPI path, /users/:id is
+// /users/{id}, and return the names of its parameters. Wildcards are named
+// wildcard1, wildcard2, ...
+func convertPath(path string) (string, []string) {
+	var (
+		params    []string
+		wildcards int
+	)
+	converted := pathParam.ReplaceAllStringFunc(path, func(match string) string {
+		name := pathParam.FindStringSubmatch(match)[1]
+		if name == "" {
+			wildcards++
+			name = "wildcard" + strconv.Itoa(wildcards)
+		}
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	if converted == "" {
+		converted = "/"
+	}
+	return converted, params
+}
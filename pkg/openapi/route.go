@@ -0,0 +1,111 @@
+package openapi
+
+type (
+	// Route is a registered route, its methods annotate the operation
+	// documenting it, e.g.
+	//
+	//	s.GET("/users/:id", handler).
+	//		Summary("Get a user").
+	//		PathParam("id", 0, "User ID").
+	//		Response(200, openapi.Fields{"data": models.User{}}, "")
+	//
+	// The values are Go values documented by their type, see Schema.
+	Route struct {
+		method    string
+		path      string
+		hidden    bool
+		info      Operation
+		params    []*param
+		body      interface{}
+		responses []*response
+	}
+	param struct {
+		in          string
+		name        string
+		description string
+		required    bool
+		value       interface{}
+	}
+	response struct {
+		status      int
+		description string
+		value       interface{}
+	}
+)
+
+// Summary set the short summary of the operation
+func (r *Route) Summary(summary string) *Route {
+	r.info.Summary = summary
+	return r
+}
+
+// Description set the description of the operation, CommonMark is allowed
+func (r *Route) Description(description string) *Route {
+	r.info.Description = description
+	return r
+}
+
+// ID set the operation ID, unique in the document
+func (r *Route) ID(operationID string) *Route {
+	r.info.OperationID = operationID
+	return r
+}
+
+// Tags group the operation in the documentation
+func (r *Route) Tags(tags ...string) *Route {
+	r.info.Tags = append(r.info.Tags, tags...)
+	return r
+}
+
+// PathParam document a path parameter, the parameters of the path that are
+// not documented are strings
+func (r *Route) PathParam(name string, value interface{}, description string) *Route {
+	return r.param("path", name, value, description, true)
+}
+
+// Query document an optional query parameter
+func (r *Route) Query(name string, value interface{}, description string) *Route {
+	return r.param("query", name, value, description, false)
+}
+
+// Header document a request header
+func (r *Route) Header(name string, value interface{}, description string, required bool) *Route {
+	return r.param("header", name, value, description, required)
+}
+
+func (r *Route) param(in string, name string, value interface{}, description string, required bool) *Route {
+	r.params = append(r.params, &param{in: in, name: name, description: description, required: required, value: value})
+	return r
+}
+
+// Body document the JSON request body
+func (r *Route) Body(value interface{}) *Route {
+	r.body = value
+	return r
+}
+
+// Response document a response, a nil value has no body and an empty
+// description is the status text
+func (r *Route) Response(status int, value interface{}, description string) *Route {
+	r.responses = append(r.responses, &response{status: status, description: description, value: value})
+	return r
+}
+
+// Deprecated mark the operation deprecated
+func (r *Route) Deprecated() *Route {
+	r.info.Deprecated = true
+	return r
+}
+
+// Security require the security scheme, registered with
+// Registry.SecurityScheme, with the scopes or roles it needs
+func (r *Route) Security(scheme string, scopes ...string) *Route {
+	r.info.Security = append(r.info.Security, SecurityRequirement{scheme: append([]string{}, scopes...)})
+	return r
+}
+
+// Hidden leave the route out of the document
+func (r *Route) Hidden() *Route {
+	r.hidden = true
+	return r
+}
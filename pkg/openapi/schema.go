@@ -0,0 +1,274 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	// Schema is a JSON Schema (2020-12) of a value. The annotations take Go
+	// values and document them by their type: the structs are components
+	// named after the type, their properties are the JSON names of the fields
+	// and the go-playground/validator tags set the constraints (required,
+	// min, max, len, oneof, email, url, uuid). A *Schema is used as is.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 interface{}        `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		AllOf                []*Schema          `json:"allOf,omitempty"`
+		Enum                 []interface{}      `json:"enum,omitempty"`
+		MinLength            *int               `json:"minLength,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		MaxItems             *int               `json:"maxItems,omitempty"`
+	}
+
+	// Fields is an object value, its properties are the schemas of the
+	// values, e.g. Fields{"data": models.User{}}
+	Fields map[string]interface{}
+
+	extended struct {
+		base   interface{}
+		fields Fields
+	}
+
+	generator struct {
+		schemas map[string]*Schema
+		names   map[reflect.Type]string
+	}
+)
+
+// Extend return a value documented as base with the fields replaced, e.g. a
+// database.Pagination whose data is a list of users:
+// Extend(database.Pagination{}, Fields{"data": []models.User{}})
+func Extend(base interface{}, fields Fields) interface{} {
+	return extended{base: base, fields: fields}
+}
+
+// Schemas of the types that are not documented by their kind
+var knownTypes = map[reflect.Type]Schema{
+	reflect.TypeOf(time.Time{}):      {Type: "string", Format: "date-time"},
+	reflect.TypeOf(gorm.DeletedAt{}): {Type: []string{"string", "null"}, Format: "date-time"},
+}
+
+const componentsPrefix = "#/components/schemas/"
+
+func newGenerator() *generator {
+	return &generator{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+func (g *generator) schema(value interface{}) *Schema {
+	switch v := value.(type) {
+	case nil:
+		return &Schema{}
+	case *Schema:
+		return v
+	case Fields:
+		return g.object(v)
+	case extended:
+		return &Schema{AllOf: []*Schema{g.schema(v.base), g.object(v.fields)}}
+	}
+	return g.typeSchema(reflect.TypeOf(value))
+}
+
+func (g *generator) object(fields Fields) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for name, value := range fields {
+		s.Properties[name] = g.schema(value)
+	}
+	return s
+}
+
+func (g *generator) typeSchema(t reflect.Type) *Schema {
+	if known, ok := knownTypes[t]; ok {
+		return &known
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.name(t)
+			g.names[t] = name
+			// Registered before the fields so that recursive types end
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: componentsPrefix + name}
+	}
+	// Interfaces and the kinds without JSON encoding accept any value
+	return &Schema{}
+}
+
+// name return the component name of a struct type, its name qualified by its
+// package when another type has it, e.g. utils.ErrorResponse
+func (g *generator) name(t reflect.Type) string {
+	if _, taken := g.schemas[t.Name()]; !taken {
+		return t.Name()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+// fields add the properties of the fields of t to s, the fields of embedded
+// structs are promoted like encoding/json does
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.fields(fieldType, s)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.typeSchema(field.Type)
+		if validate(property, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = property
+	}
+}
+
+// validate set the constraints of a validator tag on s and report whether the
+// value is required, a required string is not empty. Alternatives (a|b) and the rules without JSON Schema
+// equivalent are not documented.
+func validate(s *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "min", "gte":
+			setMin(s, param)
+		case "max", "lte":
+			setMax(s, param)
+		case "len":
+			setMin(s, param)
+			setMax(s, param)
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				if n, err := strconv.ParseFloat(value, 64); err == nil && s.Type != "string" {
+					s.Enum = append(s.Enum, n)
+				} else {
+					s.Enum = append(s.Enum, value)
+				}
+			}
+		}
+	}
+	// The validator rejects the zero value of a required field
+	if required && s.Type == "string" && s.MinLength == nil {
+		s.MinLength = length(1)
+	}
+	return required
+}
+
+// setMin set the length, the number of items or the value minimum depending
+// on the type, like the validator does
+func setMin(s *Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		s.MinLength = length(n)
+	case "array":
+		s.MinItems = length(n)
+	case "integer", "number":
+		s.Minimum = float(n)
+	}
+}
+
+func setMax(s *Schema, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		s.MaxLength = length(n)
+	case "array":
+		s.MaxItems = length(n)
+	case "integer", "number":
+		s.Maximum = float(n)
+	}
+}
+
+func length(n float64) *int {
+	i := int(n)
+	return &i
+}
+
+// IntegerRange return the schema of an integer from minimum to maximum, 0
+// leaves the maximum out, e.g. for the pagination query parameters
+func IntegerRange(minimum int, maximum int) *Schema {
+	s := &Schema{Type: "integer", Minimum: float(float64(minimum))}
+	if maximum > 0 {
+		s.Maximum = float(float64(maximum))
+	}
+	return s
+}
+
+func float(n float64) *float64 {
+	return &n
+}
@@ -0,0 +1,390 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	// Violation is a value of a request or a response that does not match the
+	// document
+	Violation struct {
+		// path, query, header, body or response
+		In string `json:"in"`
+		// Parameter name, or JSON pointer of the body value, e.g. /email
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	}
+
+	// ValidationError is a request or a response that does not match the
+	// document, with every violation
+	ValidationError struct {
+		Code       int
+		Message    string
+		Violations []Violation
+	}
+
+	// Validator validate the requests and responses of the operations of a
+	// document
+	Validator struct {
+		document *Document
+		routes   []*validatorRoute
+	}
+	validatorRoute struct {
+		method    string
+		segments  []string
+		operation *Operation
+	}
+)
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// NewValidator is the constructor function for Validator
+func NewValidator(document *Document) *Validator {
+	v := &Validator{document: document}
+	for path, item := range document.Paths {
+		for method, operation := range item {
+			v.routes = append(v.routes, &validatorRoute{
+				method:    method,
+				segments:  strings.Split(strings.Trim(path, "/"), "/"),
+				operation: operation,
+			})
+		}
+	}
+	// The static segments win over the parameters, /users/me over /users/{id}
+	sort.Slice(v.routes, func(i, j int) bool {
+		return v.routes[i].static() > v.routes[j].static()
+	})
+	return v
+}
+
+func (r *validatorRoute) static() int {
+	n := 0
+	for _, segment := range r.segments {
+		if !strings.HasPrefix(segment, "{") {
+			n++
+		}
+	}
+	return n
+}
+
+// Find return the operation documenting a request and the values of its path
+// parameters, nil when the request is not documented
+func (v *Validator) Find(method string, path string) (*Operation, map[string]string) {
+	method = strings.ToLower(method)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range v.routes {
+		if route.method != method || len(route.segments) != len(segments) {
+			continue
+		}
+		params := map[string]string{}
+		matched := true
+		for i, segment := range route.segments {
+			if strings.HasPrefix(segment, "{") {
+				params[strings.Trim(segment, "{}")] = segments[i]
+			} else if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route.operation, params
+		}
+	}
+	return nil, nil
+}
+
+// Parameter validate the value of a parameter, present reports whether the
+// request carries it
+func (v *Validator) Parameter(parameter *Parameter, value string, present bool) []Violation {
+	if !present {
+		if parameter.Required {
+			return []Violation{{In: parameter.In, Name: parameter.Name, Reason: "is required"}}
+		}
+		return nil
+	}
+
+	schema := v.resolve(parameter.Schema)
+	decoded, ok := decodeParameter(schema, value)
+	if !ok {
+		return []Violation{{In: parameter.In, Name: parameter.Name, Reason: "must be " + describeType(schema.Type)}}
+	}
+	var violations []Violation
+	v.validate(schema, decoded, parameter.In, "", &violations)
+	for i := range violations {
+		violations[i].Name = parameter.Name
+	}
+	return violations
+}
+
+// Body validate a JSON request body against the request body of the
+// operation
+func (v *Validator) Body(requestBody *RequestBody, contentType string, body []byte) []Violation {
+	media, ok := requestBody.Content[MIMEApplicationJSON]
+	if !ok {
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if requestBody.Required {
+			return []Violation{{In: "body", Reason: "is required"}}
+		}
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != MIMEApplicationJSON {
+		return []Violation{{In: "header", Name: "Content-Type", Reason: "must be " + MIMEApplicationJSON}}
+	}
+	return v.json(media.Schema, body, "body")
+}
+
+// Response validate a response against the responses of the operation, the
+// status must be documented (or a default response) and a JSON body must
+// match its schema
+func (v *Validator) Response(operation *Operation, status int, contentType string, body []byte) []Violation {
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		response, ok = operation.Responses["default"]
+	}
+	if !ok {
+		return []Violation{{In: "response", Name: "status", Reason: fmt.Sprintf("%d is not documented", status)}}
+	}
+
+	media, ok := response.Content[MIMEApplicationJSON]
+	if !ok || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != MIMEApplicationJSON {
+		return []Violation{{In: "response", Name: "Content-Type", Reason: "must be " + MIMEApplicationJSON}}
+	}
+	return v.json(media.Schema, body, "response")
+}
+
+func (v *Validator) json(schema *Schema, body []byte, in string) []Violation {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []Violation{{In: in, Reason: "invalid JSON: " + err.Error()}}
+	}
+
+	var violations []Violation
+	v.validate(schema, value, in, "", &violations)
+	return violations
+}
+
+// resolve return the schema a $ref points to
+func (v *Validator) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = v.document.Components.Schemas[strings.TrimPrefix(schema.Ref, componentsPrefix)]
+	}
+	if schema == nil {
+		return &Schema{}
+	}
+	return schema
+}
+
+// validate add the violations of a JSON value, decoded with UseNumber, at
+// pointer
+func (v *Validator) validate(schema *Schema, value interface{}, in string, pointer string, violations *[]Violation) {
+	schema = v.resolve(schema)
+	violation := func(reason string) {
+		*violations = append(*violations, Violation{In: in, Name: pointer, Reason: reason})
+	}
+
+	for _, s := range schema.AllOf {
+		v.validate(s, value, in, pointer, violations)
+	}
+	if schema.Type != nil && !matchType(schema.Type, value) {
+		violation("must be " + describeType(schema.Type))
+		return
+	}
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		violation(fmt.Sprintf("must be one of %v", schema.Enum))
+	}
+
+	switch value := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(value)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				violation("must not be empty")
+			} else {
+				violation(fmt.Sprintf("must be at least %d characters", *schema.MinLength))
+			}
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			violation(fmt.Sprintf("must be at most %d characters", *schema.MaxLength))
+		}
+		if schema.Format != "" && !matchFormat(schema.Format, value) {
+			violation("must be a valid " + schema.Format)
+		}
+	case json.Number:
+		n, _ := value.Float64()
+		if schema.Minimum != nil && n < *schema.Minimum {
+			violation(fmt.Sprintf("must be %v or more", *schema.Minimum))
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			violation(fmt.Sprintf("must be %v or less", *schema.Maximum))
+		}
+	case []interface{}:
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			violation(fmt.Sprintf("must have at least %d items", *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			violation(fmt.Sprintf("must have at most %d items", *schema.MaxItems))
+		}
+		if schema.Items != nil {
+			for i, item := range value {
+				v.validate(schema.Items, item, in, pointer+"/"+strconv.Itoa(i), violations)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				*violations = append(*violations, Violation{In: in, Name: pointer + "/" + name, Reason: "is required"})
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := schema.Properties[name]; ok {
+				v.validate(property, value[name], in, pointer+"/"+name, violations)
+			} else if schema.AdditionalProperties != nil {
+				v.validate(schema.AdditionalProperties, value[name], in, pointer+"/"+name, violations)
+			}
+		}
+	}
+}
+
+// decodeParameter decode a parameter value to the JSON value of its schema
+// type
+func decodeParameter(schema *Schema, value string) (interface{}, bool) {
+	switch {
+	case hasType(schema.Type, "integer"):
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(value), true
+	case hasType(schema.Type, "number"):
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(value), true
+	case hasType(schema.Type, "boolean"):
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	}
+	return value, true
+}
+
+func types(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func hasType(t interface{}, name string) bool {
+	for _, candidate := range types(t) {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+func describeType(t interface{}) string {
+	names := append([]string(nil), types(t)...)
+	for i, name := range names {
+		switch name {
+		case "integer", "array", "object":
+			names[i] = "an " + name
+		case "null":
+		default:
+			names[i] = "a " + name
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+func matchType(t interface{}, value interface{}) bool {
+	for _, name := range types(t) {
+		switch value := value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case json.Number:
+			if name == "number" {
+				return true
+			}
+			if _, err := value.Int64(); err == nil && name == "integer" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if fmt.Sprint(candidate) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// matchFormat check the formats set by the validate tags and the known types,
+// the others are annotations
+func matchFormat(format string, value string) bool {
+	switch format {
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	// Counter add to expiring counters, cache.Storage implement it atomically
+	// with Redis INCRBY so that the processes and replicas share the counts
+	Counter interface {
+		Increment(key string, by int, exp time.Duration) (int, error)
+		Count(key string) (int, error)
+	}
+	// SlidingWindow limit the cost spent per key over a window sliding with
+	// time. The count of the previous fixed window is weighted by how much of it
+	// still overlaps the sliding window, so bursts at window boundaries cannot
+	// double the limit.
+	SlidingWindow struct {
+		counter Counter
+		window  time.Duration
+		now     func() time.Time
+	}
+	// Result is the outcome of a Take
+	Result struct {
+		Allowed   bool
+		Limit     int
+		Remaining int
+		// Reset is the time until the current window ends
+		Reset time.Duration
+		// RetryAfter is the time until the denied request would be allowed
+		RetryAfter time.Duration
+	}
+
+	storageCounter struct {
+		mu      sync.Mutex
+		storage fiber.Storage
+	}
+)
+
+// NewSlidingWindow is the constructor function for SlidingWindow
+func NewSlidingWindow(counter Counter, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{
+		counter: counter,
+		window:  window,
+		now:     time.Now,
+	}
+}
+
+// StorageCounter return the storage as a Counter. Storages that are not
+// counters are incremented with Get and Set, atomic in this process only.
+func StorageCounter(storage fiber.Storage) Counter {
+	if counter, ok := storage.(Counter); ok {
+		return counter
+	}
+	return &storageCounter{storage: storage}
+}
+
+// Take spend cost from the limit of key, nothing is spent when the request is
+// denied
+func (w *SlidingWindow) Take(key string, limit int, cost int) (*Result, error) {
+	now := w.now()
+	start := now.Truncate(w.window)
+	elapsed := now.Sub(start)
+	currentKey := fmt.Sprintf("%s:%d", key, start.Unix())
+	previousKey := fmt.Sprintf("%s:%d", key, start.Add(-w.window).Unix())
+
+	previous, err := w.counter.Count(previousKey)
+	if err != nil {
+		return nil, err
+	}
+	// Increment first so that concurrent requests cannot all pass the check
+	current, err := w.counter.Increment(currentKey, cost, 2*w.window)
+	if err != nil {
+		return nil, err
+	}
+
+	overlap := float64(w.window-elapsed) / float64(w.window)
+	used := int(math.Ceil(float64(previous)*overlap)) + current
+
+	result := &Result{
+		Allowed: used <= limit,
+		Limit:   limit,
+		Reset:   w.window - elapsed,
+	}
+	if !result.Allowed {
+		if _, err := w.counter.Increment(currentKey, -cost, 2*w.window); err != nil {
+			return nil, err
+		}
+		used -= cost
+		result.RetryAfter = w.retryAfter(elapsed, previous, current-cost, limit, cost)
+	}
+	result.Remaining = max(limit-used, 0)
+
+	return result, nil
+}
+
+// retryAfter return the time until previous and current weigh little enough
+// for cost to fit in the limit
+func (w *SlidingWindow) retryAfter(elapsed time.Duration, previous int, current int, limit int, cost int) time.Duration {
+	window := float64(w.window)
+
+	// Still in the current window, the previous window count fades out
+	if room := float64(limit - cost - current); room >= 0 && previous > 0 {
+		return time.Duration(window - float64(elapsed) - room*window/float64(previous))
+	}
+
+	// In the next window, the current count becomes the one fading out
+	if cost > limit {
+		return 2*w.window - elapsed
+	}
+	wait := 0.0
+	if current > 0 {
+		wait = math.Max(window-float64(limit-cost)*window/float64(current), 0)
+	}
+	return w.window - elapsed + time.Duration(wait)
+}
+
+func (c *storageCounter) Increment(key string, by int, exp time.Duration) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count, err := c.count(key)
+	if err != nil {
+		return 0, err
+	}
+	count += by
+	return count, c.storage.Set(key, []byte(strconv.Itoa(count)), exp)
+}
+
+func (c *storageCounter) Count(key string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count(key)
+}
+
+func (c *storageCounter) count(key string) (int, error) {
+	val, err := c.storage.Get(key)
+	if err != nil || len(val) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(string(val))
+}
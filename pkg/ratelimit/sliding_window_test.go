@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	const window = time.Minute
+	start := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// Counts of the previous and current windows before the request
+		previous int
+		current  int
+		elapsed  time.Duration
+		limit    int
+		cost     int
+
+		wantAllowed    bool
+		wantRemaining  int
+		wantReset      time.Duration
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:    "empty window",
+			elapsed: 10 * time.Second, limit: 10, cost: 1,
+			wantAllowed: true, wantRemaining: 9, wantReset: 50 * time.Second,
+		},
+		{
+			name:     "previous window weighted by its overlap",
+			previous: 10, elapsed: 30 * time.Second, limit: 10, cost: 1,
+			wantAllowed: true, wantRemaining: 4, wantReset: 30 * time.Second,
+		},
+		{
+			name:     "burst at the window boundary",
+			previous: 10, elapsed: 0, limit: 10, cost: 1,
+			wantAllowed: false, wantRemaining: 0, wantReset: window,
+			// The previous count must fade to 9: 60s - 9*60s/10
+			wantRetryAfter: 6 * time.Second,
+		},
+		{
+			name:     "denied while the previous window fades out",
+			previous: 10, current: 2, elapsed: 15 * time.Second, limit: 10, cost: 1,
+			wantAllowed: false, wantRemaining: 0, wantReset: 45 * time.Second,
+			// At 18s the previous window weighs 7, 7+2+1 fits
+			wantRetryAfter: 3 * time.Second,
+		},
+		{
+			name:    "denied until the next window",
+			current: 10, elapsed: 20 * time.Second, limit: 10, cost: 1,
+			wantAllowed: false, wantRemaining: 0, wantReset: 40 * time.Second,
+			// 6s into the next window the current count weighs 9
+			wantRetryAfter: 46 * time.Second,
+		},
+		{
+			name:    "cost",
+			current: 4, elapsed: 20 * time.Second, limit: 10, cost: 5,
+			wantAllowed: true, wantRemaining: 1, wantReset: 40 * time.Second,
+		},
+		{
+			name:    "cost above the limit",
+			elapsed: 20 * time.Second, limit: 10, cost: 11,
+			wantAllowed: false, wantRemaining: 10, wantReset: 40 * time.Second,
+			wantRetryAfter: 100 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := cache.NewMemoryStorage()
+			w := NewSlidingWindow(counter, window)
+			w.now = func() time.Time { return start.Add(tt.elapsed) }
+
+			currentKey := fmt.Sprintf("client:%d", start.Unix())
+			counter.Increment(fmt.Sprintf("client:%d", start.Add(-window).Unix()), tt.previous, 2*window)
+			counter.Increment(currentKey, tt.current, 2*window)
+
+			result, err := w.Take("client", tt.limit, tt.cost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", result.Allowed, tt.wantAllowed)
+			}
+			if result.Limit != tt.limit || result.Remaining != tt.wantRemaining {
+				t.Errorf("Limit, Remaining = %d, %d, want %d, %d", result.Limit, result.Remaining, tt.limit, tt.wantRemaining)
+			}
+			if result.Reset != tt.wantReset {
+				t.Errorf("Reset = %s, want %s", result.Reset, tt.wantReset)
+			}
+			if result.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("RetryAfter = %s, want %s", result.RetryAfter, tt.wantRetryAfter)
+			}
+
+			// A denied request spends nothing
+			want := tt.current
+			if tt.wantAllowed {
+				want += tt.cost
+			}
+			if count, _ := counter.Count(currentKey); count != want {
+				t.Errorf("current count = %d, want %d", count, want)
+			}
+		})
+	}
+}
+
+func TestSlidingWindowRetryAfter(t *testing.T) {
+	const window = time.Minute
+	start := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	now := start.Add(15 * time.Second)
+
+	w := NewSlidingWindow(cache.NewMemoryStorage(), window)
+	w.now = func() time.Time { return now }
+
+	// Spend the limit, then the request is allowed again after Retry-After and
+	// not before
+	for i := 0; i < 10; i++ {
+		w.Take("client", 10, 1)
+	}
+	result, _ := w.Take("client", 10, 1)
+	if result.Allowed {
+		t.Fatal("request allowed over the limit")
+	}
+	retryAt := now.Add(result.RetryAfter)
+
+	now = retryAt.Add(-time.Second)
+	if result, _ := w.Take("client", 10, 1); result.Allowed {
+		t.Errorf("request allowed 1s before Retry-After (%s)", result.RetryAfter)
+	}
+	now = retryAt
+	if result, _ := w.Take("client", 10, 1); !result.Allowed {
+		t.Errorf("request denied at Retry-After, retry after %s", result.RetryAfter)
+	}
+}
+
+// mapStorage is a fiber.Storage that is not a Counter
+type mapStorage map[string][]byte
+
+func (m mapStorage) Get(key string) ([]byte, error) { return m[key], nil }
+func (m mapStorage) Set(key string, val []byte, exp time.Duration) error {
+	m[key] = val
+	return nil
+}
+func (m mapStorage) Delete(key string) error { delete(m, key); return nil }
+func (m mapStorage) Reset() error            { return nil }
+func (m mapStorage) Close() error            { return nil }
+
+func TestStorageCounter(t *testing.T) {
+	counter := StorageCounter(mapStorage{})
+	for i, by := range []int{3, 2, -1} {
+		count, err := counter.Increment("key", by, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{3, 5, 4}[i]; count != want {
+			t.Errorf("Increment(%d) = %d, want %d", by, count, want)
+		}
+	}
+	if count, _ := counter.Count("missing"); count != 0 {
+		t.Errorf("Count() = %d for a missing key, want 0", count)
+	}
+}
@@ -0,0 +1,56 @@
+package requestid
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys, gRPC lowercases them
+const (
+	metadataKey            = "x-request-id"
+	metadataKeyTraceparent = "traceparent"
+)
+
+// UnaryServerInterceptor put the request ID of every unary gRPC call in its
+// context and echo it in the response header metadata
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = incoming(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataKey, FromContext(ctx)))
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor put the request ID of every streaming gRPC call in
+// its context and echo it in the response header metadata
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := incoming(stream.Context())
+		_ = stream.SetHeader(metadata.Pairs(metadataKey, FromContext(ctx)))
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// serverStream override the context of a stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return NewContext(ctx, Resolve(first(md, metadataKey), first(md, metadataKeyTraceparent)))
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
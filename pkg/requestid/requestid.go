@@ -0,0 +1,71 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Header is the header carrying the request ID, it is also read from and
+// echoed in the gRPC metadata (lowercased)
+const Header = "X-Request-ID"
+
+// Longest inbound request ID accepted, longer ones are replaced
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext return a copy of ctx carrying the request ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext return the request ID carried by ctx, empty when there is none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Resolve return the ID of an inbound request: the X-Request-ID of the client
+// when it is valid, else the trace ID of its W3C traceparent, else a new one
+func Resolve(requestID string, traceparent string) string {
+	if valid(requestID) {
+		return requestID
+	}
+	if traceID := traceID(traceparent); traceID != "" {
+		return traceID
+	}
+	return utils.UUIDv4()
+}
+
+// valid accept short IDs made of letters, digits and . _ : - so that a client
+// cannot inject anything in the logs
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._:-", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// traceID return the trace ID of a "00-<trace id>-<parent id>-<flags>"
+// traceparent, empty when it is malformed
+func traceID(traceparent string) string {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if strings.Trim(traceID, "0") == "" || strings.Trim(traceID, "0123456789abcdef") != "" {
+		return ""
+	}
+	return traceID
+}
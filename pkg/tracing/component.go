@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/gofiber/fiber/v2"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ComponentName is the name of the tracing component
+const ComponentName = "tracing"
+
+// Component manage the OpenTelemetry tracer provider, it does nothing when no
+// exporter endpoint is configured
+type Component struct {
+	config        *config.Config
+	traceProvider *sdktrace.TracerProvider
+	tracer        trace.Tracer
+	exporter      *exporter
+}
+
+// NewComponent is the constructor function for the tracing component
+func NewComponent(config *config.Config) *Component {
+	return &Component{config: config}
+}
+
+func (c *Component) Name() string {
+	return ComponentName
+}
+
+func (c *Component) Start(ctx context.Context) error {
+	traceProvider, tracer, exporter, err := initTracer(ctx, c.config)
+	if err != nil {
+		return err
+	}
+	c.traceProvider, c.tracer, c.exporter = traceProvider, tracer, exporter
+	return nil
+}
+
+// Stop export the pending spans and close the exporter connection
+func (c *Component) Stop(ctx context.Context) error {
+	if c.traceProvider == nil {
+		return nil
+	}
+	return c.traceProvider.Shutdown(ctx)
+}
+
+func (c *Component) Health(ctx context.Context) error {
+	_, err := c.Check(ctx)
+	return err
+}
+
+// Check report the result of the last span export
+func (c *Component) Check(ctx context.Context) (any, error) {
+	if c.exporter == nil {
+		return fiber.Map{"enabled": false}, nil
+	}
+
+	details := fiber.Map{
+		"enabled":  true,
+		"endpoint": c.config.OpenTelemetry.OtelExporterOTLPEndpoint,
+	}
+	last := c.exporter.last.Load()
+	if last == nil {
+		return details, nil
+	}
+	details["last_export"] = last.at.UTC()
+	details["last_export_spans"] = last.spans
+	if last.err != nil {
+		return details, fmt.Errorf("last export failed: %w", last.err)
+	}
+	return details, nil
+}
+
+// Tracer return the service tracer, nil when tracing is off
+func (c *Component) Tracer() trace.Tracer {
+	return c.tracer
+}
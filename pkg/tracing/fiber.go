@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// headerCarrier adapt the request headers to the OpenTelemetry propagators
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key string, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// Extract return ctx carrying the trace of the request headers (traceparent),
+// the spans of the handlers continue the trace of the caller
+func Extract(ctx context.Context, c *fiber.Ctx) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier{c: c})
+}
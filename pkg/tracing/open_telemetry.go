@@ -3,8 +3,11 @@ package tracing
 import (
 	"context"
 	"log"
+	"sync/atomic"
+	"time"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/requestid"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils/color"
 	"github.com/gofiber/fiber/v2"
 	"go.opentelemetry.io/otel"
@@ -19,9 +22,33 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-func InitTracer(config *config.Config) (*sdktrace.TracerProvider, trace.Tracer) {
+// exporter record the result of the last export, it is the health of the
+// tracing pipeline
+type exporter struct {
+	sdktrace.SpanExporter
+	last atomic.Pointer[exportResult]
+}
+
+type exportResult struct {
+	at    time.Time
+	spans int
+	err   error
+}
+
+func (e *exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.last.Store(&exportResult{at: time.Now(), spans: len(spans), err: err})
+	return err
+}
+
+func InitTracer(ctx context.Context, config *config.Config) (*sdktrace.TracerProvider, trace.Tracer, error) {
+	tp, tracer, _, err := initTracer(ctx, config)
+	return tp, tracer, err
+}
+
+func initTracer(ctx context.Context, config *config.Config) (*sdktrace.TracerProvider, trace.Tracer, *exporter, error) {
 	if config.OpenTelemetry.OtelExporterOTLPEndpoint == "" {
-		return nil, nil
+		return nil, nil, nil, nil
 	}
 
 	secureOption := otlptracegrpc.WithTLSCredentials(credentials.NewClientTLSFromCert(nil, ""))
@@ -29,19 +56,20 @@ func InitTracer(config *config.Config) (*sdktrace.TracerProvider, trace.Tracer)
 		secureOption = otlptracegrpc.WithInsecure()
 	}
 
-	exporter, err := otlptrace.New(
-		context.Background(),
+	otlpExporter, err := otlptrace.New(
+		ctx,
 		otlptracegrpc.NewClient(
 			secureOption,
 			otlptracegrpc.WithEndpoint(config.OpenTelemetry.OtelExporterOTLPEndpoint),
 		),
 	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, nil, err
 	}
+	exporter := &exporter{SpanExporter: otlpExporter}
 
 	resources, err := resource.New(
-		context.Background(),
+		ctx,
 		resource.WithAttributes(
 			attribute.String("service.name", config.App.ServiceName),
 			attribute.String("library.language", "go"),
@@ -70,14 +98,18 @@ func InitTracer(config *config.Config) (*sdktrace.TracerProvider, trace.Tracer)
 	// Set main tracer
 	tracer := otel.Tracer(config.App.ServiceName)
 
-	return tp, tracer
+	return tp, tracer, exporter, nil
 }
 
+// TraceStart start a span, it is tagged with the request ID of ctx
 func TraceStart(ctx context.Context, tracer trace.Tracer, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
 	if tracer == nil {
 		return ctx, nil
 	}
 
+	if id := requestid.FromContext(ctx); id != "" {
+		opts = append(opts, trace.WithAttributes(attribute.String("request.id", id)))
+	}
 	return tracer.Start(ctx, spanName, opts...)
 }
 
@@ -86,9 +118,3 @@ func TraceEnd(span trace.Span) {
 		span.End()
 	}
 }
-
-func Cleanup(traceProvider *sdktrace.TracerProvider) {
-	if err := traceProvider.Shutdown(context.Background()); err != nil {
-		log.Printf("Error shutting down tracer provider: %v", err)
-	}
-}
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/requestid"
 	"github.com/getsentry/sentry-go"
 )
 
@@ -36,7 +37,7 @@ func HandleErrors(ctx context.Context, errs ...error) {
 			log.Println(
 				"[ERROR]",
 				"txn_id:",
-				ctx.Value("requestid"),
+				requestid.FromContext(ctx),
 				"|",
 				"file:",
 				fns[len(fns)-1],
@@ -46,7 +47,22 @@ func HandleErrors(ctx context.Context, errs ...error) {
 				err.Error(),
 			)
 
-			sentry.CaptureException(err)
+			captureException(ctx, err)
 		}
 	}
 }
+
+// captureException report err to the Sentry hub of the request, the event is
+// tagged with the request ID
+func captureException(ctx context.Context, err error) {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		if id := requestid.FromContext(ctx); id != "" {
+			scope.SetTag("request_id", id)
+		}
+		hub.CaptureException(err)
+	})
+}
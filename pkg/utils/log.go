@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/requestid"
+)
+
+// Log print a request log line prefixed with the request ID of ctx, in the
+// format of HandleErrors
+func Log(ctx context.Context, v ...interface{}) {
+	log.Println(append([]interface{}{"txn_id:", requestid.FromContext(ctx), "|"}, v...)...)
+}
+
+// Logf is Log with a format
+func Logf(ctx context.Context, format string, v ...interface{}) {
+	Log(ctx, fmt.Sprintf(format, v...))
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	AdminHandler interface {
+		GetConfig(c *fiber.Ctx) error
+		FlushCacheTag(c *fiber.Ctx) error
+		DeleteCacheKey(c *fiber.Ctx) error
+	}
+	adminHandler struct {
+		// Running config, it is replaced on reload
+		config func() *config.Config
+		cacher cache.Cacher
+	}
+)
+
+func NewAdminHandler(config func() *config.Config, cacher cache.Cacher) adminHandler {
+	return adminHandler{
+		config: config,
+		cacher: cacher,
+	}
+}
+
+// GetConfig dump the running configuration and where each value came from,
+// secrets are redacted
+func (h adminHandler) GetConfig(c *fiber.Ctx) error {
+	config := h.config()
+	return c.JSON(fiber.Map{
+		"profile": config.Profile.Name,
+		"file":    config.File,
+		"values":  config.Values(),
+	})
+}
+
+// FlushCacheTag delete every cached value set with the tag
+func (h adminHandler) FlushCacheTag(c *fiber.Ctx) error {
+	if h.cacher == nil {
+		return fiber.ErrServiceUnavailable
+	}
+	if err := h.cacher.Tag(c.Params("tag")).Flush(c.Context()); err != nil {
+		utils.Log(c.UserContext(), "FlushCacheTag error:", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
+
+// DeleteCacheKey delete a single cached value
+func (h adminHandler) DeleteCacheKey(c *fiber.Ctx) error {
+	if h.cacher == nil {
+		return fiber.ErrServiceUnavailable
+	}
+	if err := h.cacher.Delete(c.Context(), c.Params("key")); err != nil {
+		utils.Log(c.UserContext(), "DeleteCacheKey error:", err)
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(fiber.Map{
+		"code":    "0",
+		"message": "OK",
+	})
+}
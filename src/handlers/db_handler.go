@@ -15,7 +15,7 @@ type (
 
 func (h handler) CheckDatabaseConnection(c *fiber.Ctx) error {
 	var (
-		ctx, span = tracing.TraceStart(c.Context(), h.tracer, "CheckDatabaseConnectionHandler", trace.WithAttributes(attribute.String("handler", "CheckDatabaseConnection")))
+		ctx, span = tracing.TraceStart(c.UserContext(), h.tracer, "CheckDatabaseConnectionHandler", trace.WithAttributes(attribute.String("handler", "CheckDatabaseConnection")))
 	)
 
 	// Call service function
@@ -14,7 +14,7 @@ import (
 type (
 	// Register handler services
 	handler struct {
-		cacher       *cache.Cache
+		cacher       cache.Cacher
 		tracer       trace.Tracer
 		userService  services.UserService
 		dbRepository repositories.DbRepository
@@ -27,7 +27,7 @@ type (
 )
 
 func NewHandler(
-	cacher *cache.Cache,
+	cacher cache.Cacher,
 	tracer trace.Tracer,
 	dbRepository repositories.DbRepository,
 	userService services.UserService,
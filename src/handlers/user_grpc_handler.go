@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/validator"
+	userv1 "github.com/Stream-I-T-Consulting/stream-http-service-go/proto/user/v1"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/services"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type (
+	// userGrpcHandler implement the gRPC UserService on top of services.UserService
+	userGrpcHandler struct {
+		userv1.UnimplementedUserServiceServer
+		cacher      cache.Cacher
+		tracer      trace.Tracer
+		userService services.UserService
+	}
+)
+
+func NewUserGrpcHandler(
+	cacher cache.Cacher,
+	tracer trace.Tracer,
+	userService services.UserService,
+) userv1.UserServiceServer {
+	return &userGrpcHandler{
+		cacher:      cacher,
+		tracer:      tracer,
+		userService: userService,
+	}
+}
+
+func (h *userGrpcHandler) GetUsers(ctx context.Context, req *userv1.GetUsersRequest) (*userv1.GetUsersResponse, error) {
+	ctx, span := tracing.TraceStart(ctx, h.tracer, "GetUsersGrpcHandler", trace.WithAttributes(attribute.String("handler", "GetUsers")))
+	defer tracing.TraceEnd(span)
+
+	// Get paginate values, same defaults and bounds as the REST endpoint, 0 is
+	// an unset value
+	paginate := database.Pagination{Page: 1, Limit: 20}
+	if req.Page < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page must be 1 or more")
+	}
+	if req.Limit < 0 || req.Limit > database.MaxLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", database.MaxLimit)
+	}
+	if req.Page > 0 {
+		paginate.Page = int(req.Page)
+	}
+	if req.Limit > 0 {
+		paginate.Limit = int(req.Limit)
+	}
+
+	result, err := h.userService.GetUsers(ctx, paginate, req.Search)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	users, _ := result.Data.([]models.User)
+	response := &userv1.GetUsersResponse{
+		Limit:      int32(result.Limit),
+		Page:       int32(result.Page),
+		Sort:       result.Sort,
+		TotalRows:  result.TotalRows,
+		TotalPages: int32(result.TotalPages),
+		Data:       make([]*userv1.User, 0, len(users)),
+	}
+	for _, user := range users {
+		response.Data = append(response.Data, userToProto(user))
+	}
+
+	return response, nil
+}
+
+func (h *userGrpcHandler) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+	ctx, span := tracing.TraceStart(ctx, h.tracer, "GetUserGrpcHandler", trace.WithAttributes(attribute.String("handler", "GetUser"), attribute.Int("id", int(req.Id))))
+	defer tracing.TraceEnd(span)
+
+	id, err := grpcUserID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.userService.GetUser(ctx, id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	user, _ := result["data"].(models.User)
+	return &userv1.GetUserResponse{Data: userToProto(user)}, nil
+}
+
+func (h *userGrpcHandler) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.StatusResponse, error) {
+	ctx, span := tracing.TraceStart(ctx, h.tracer, "CreateUserGrpcHandler", trace.WithAttributes(attribute.String("handler", "CreateUser")))
+	defer tracing.TraceEnd(span)
+
+	userDto := &services.UserDto{
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Email:     req.Email,
+	}
+	if err := validateGrpc(userDto); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.CreateUser(ctx, userDto); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	// Clear user cache
+	h.cacher.Tag("users").Flush(ctx)
+
+	return &userv1.StatusResponse{Code: "0", Message: "OK"}, nil
+}
+
+func (h *userGrpcHandler) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.StatusResponse, error) {
+	ctx, span := tracing.TraceStart(ctx, h.tracer, "UpdateUserGrpcHandler", trace.WithAttributes(attribute.String("handler", "UpdateUser"), attribute.Int("id", int(req.Id))))
+	defer tracing.TraceEnd(span)
+
+	id, err := grpcUserID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	userDto := &services.UserDto{
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Email:     req.Email,
+	}
+	if err := validateGrpc(userDto); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.UpdateUser(ctx, id, userDto); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	// Clear user cache
+	h.cacher.Tag("users").Flush(ctx)
+
+	return &userv1.StatusResponse{Code: "0", Message: "OK"}, nil
+}
+
+func (h *userGrpcHandler) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*userv1.StatusResponse, error) {
+	ctx, span := tracing.TraceStart(ctx, h.tracer, "DeleteUserGrpcHandler", trace.WithAttributes(attribute.String("handler", "DeleteUser"), attribute.Int("id", int(req.Id))))
+	defer tracing.TraceEnd(span)
+
+	id, err := grpcUserID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.DeleteUser(ctx, id); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	// Clear user cache
+	h.cacher.Tag("users").Flush(ctx)
+
+	return &userv1.StatusResponse{Code: "0", Message: "OK"}, nil
+}
+
+func userToProto(user models.User) *userv1.User {
+	return &userv1.User{
+		Id:        uint64(user.ID),
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
+
+// validateGrpc run the form request validation, violations are returned as
+// an InvalidArgument status with BadRequest details
+func validateGrpc(data interface{}) error {
+	errs := validator.Validate(data)
+	if errs == nil {
+		return nil
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, err := range errs {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       err.FailedField,
+			Description: fmt.Sprintf("failed on the '%s' rule %s", err.Tag, err.Value),
+		})
+	}
+
+	st, err := status.New(codes.InvalidArgument, "validation failed").WithDetails(badRequest)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "validation failed")
+	}
+	return st.Err()
+}
+
+// grpcUserID check the id of a request like userID does the path parameter
+func grpcUserID(id uint64) (int, error) {
+	if id < 1 || id > math.MaxInt {
+		return 0, status.Error(codes.InvalidArgument, "id must be a positive integer")
+	}
+	return int(id), nil
+}
+
+// grpcError map service and repository errors to gRPC status errors, the
+// unexpected errors are logged and answered with a generic message
+func grpcError(ctx context.Context, err error) error {
+	var fiberErr *fiber.Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "record not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.As(err, &fiberErr):
+		return status.Error(httpStatusToGrpcCode(fiberErr.Code), fiberErr.Message)
+	}
+	utils.HandleErrors(ctx, err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func httpStatusToGrpcCode(code int) codes.Code {
+	switch code {
+	case fiber.StatusBadRequest:
+		return codes.InvalidArgument
+	case fiber.StatusUnauthorized:
+		return codes.Unauthenticated
+	case fiber.StatusForbidden:
+		return codes.PermissionDenied
+	case fiber.StatusNotFound:
+		return codes.NotFound
+	case fiber.StatusConflict:
+		return codes.AlreadyExists
+	case fiber.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case fiber.StatusServiceUnavailable:
+		return codes.Unavailable
+	case fiber.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
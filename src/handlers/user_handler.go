@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/database"
@@ -10,6 +11,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
 type (
@@ -17,6 +19,7 @@ type (
 		// User handlers
 		GetUsers(c *fiber.Ctx) error
 		GetUser(c *fiber.Ctx) error
+		GetUserV2(c *fiber.Ctx) error
 		CreateUser(c *fiber.Ctx) error
 		UpdateUser(c *fiber.Ctx) error
 		DeleteUser(c *fiber.Ctx) error
@@ -24,18 +27,24 @@ type (
 )
 
 func (h handler) GetUsers(c *fiber.Ctx) error {
-	var (
-		ctx, span    = tracing.TraceStart(c.Context(), h.tracer, "GetUsersHandler", trace.WithAttributes(attribute.String("handler", "GetUsers")))
-		responseData *database.Pagination
-	)
-
 	// Get paginate values
 	paginate := database.Pagination{
 		Page:  c.QueryInt("page", 1),
 		Limit: c.QueryInt("limit", 20),
 	}
+	if paginate.Page < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "page must be 1 or more")
+	}
+	if paginate.Limit < 1 || paginate.Limit > database.MaxLimit {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", database.MaxLimit))
+	}
 	search := c.Query("search")
 
+	var (
+		ctx, span    = tracing.TraceStart(c.UserContext(), h.tracer, "GetUsersHandler", trace.WithAttributes(attribute.String("handler", "GetUsers")))
+		responseData *database.Pagination
+	)
+
 	// Make cache key
 	cacheTags := []string{"users"}
 	cacheKey := fmt.Sprintf("GetUsers_%d_%d", paginate.Page, paginate.Limit)
@@ -53,28 +62,53 @@ func (h handler) GetUsers(c *fiber.Ctx) error {
 }
 
 func (h handler) GetUser(c *fiber.Ctx) error {
-	var (
-		id, _        = c.ParamsInt("id")
-		ctx, span    = tracing.TraceStart(c.Context(), h.tracer, "GetUserHandler", trace.WithAttributes(attribute.String("handler", "GetUser"), attribute.Int("id", id)))
-		responseData map[string]interface{}
-	)
+	id, err := userID(c)
+	if err != nil {
+		return err
+	}
 
-	// Make cache key
-	cacheTags := []string{"users"}
-	cacheKey := fmt.Sprintf("GetUser_%d", id)
+	responseData, err := h.getUser(c, id)
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+
+	return c.JSON(responseData)
+}
+
+// GetUserV2 answer 404 for an unknown user, v1 answers 500
+func (h handler) GetUserV2(c *fiber.Ctx) error {
+	id, err := userID(c)
+	if err != nil {
+		return err
+	}
 
-	responseData, err := h.QueryCache(ctx, cacheKey, cacheTags, id, h.userService.GetUser)
+	responseData, err := h.getUser(c, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "user not found")
+	}
 	if err != nil {
 		return fiber.ErrInternalServerError
 	}
 
-	tracing.TraceEnd(span)
 	return c.JSON(responseData)
 }
 
+func (h handler) getUser(c *fiber.Ctx, id int) (map[string]interface{}, error) {
+	var (
+		ctx, span = tracing.TraceStart(c.UserContext(), h.tracer, "GetUserHandler", trace.WithAttributes(attribute.String("handler", "GetUser"), attribute.Int("id", id)))
+	)
+	defer tracing.TraceEnd(span)
+
+	// Make cache key
+	cacheTags := []string{"users"}
+	cacheKey := fmt.Sprintf("GetUser_%d", id)
+
+	return h.QueryCache(ctx, cacheKey, cacheTags, id, h.userService.GetUser)
+}
+
 func (h handler) CreateUser(c *fiber.Ctx) error {
 	var (
-		ctx, span = tracing.TraceStart(c.Context(), h.tracer, "CreateUserHandler", trace.WithAttributes(attribute.String("handler", "CreateUser")))
+		ctx, span = tracing.TraceStart(c.UserContext(), h.tracer, "CreateUserHandler", trace.WithAttributes(attribute.String("handler", "CreateUser")))
 	)
 
 	// Create data transfer object
@@ -108,9 +142,13 @@ func (h handler) CreateUser(c *fiber.Ctx) error {
 }
 
 func (h handler) UpdateUser(c *fiber.Ctx) error {
+	id, err := userID(c)
+	if err != nil {
+		return err
+	}
+
 	var (
-		id, _     = c.ParamsInt("id")
-		ctx, span = tracing.TraceStart(c.Context(), h.tracer, "UpdateUserHandler", trace.WithAttributes(attribute.String("handler", "UpdateUser"), attribute.Int("id", id)))
+		ctx, span = tracing.TraceStart(c.UserContext(), h.tracer, "UpdateUserHandler", trace.WithAttributes(attribute.String("handler", "UpdateUser"), attribute.Int("id", id)))
 	)
 
 	// Create data transfer object
@@ -128,7 +166,7 @@ func (h handler) UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// Call service function
-	err := h.userService.UpdateUser(ctx, id, userDto)
+	err = h.userService.UpdateUser(ctx, id, userDto)
 	if err != nil {
 		return err
 	}
@@ -144,13 +182,17 @@ func (h handler) UpdateUser(c *fiber.Ctx) error {
 }
 
 func (h handler) DeleteUser(c *fiber.Ctx) error {
+	id, err := userID(c)
+	if err != nil {
+		return err
+	}
+
 	var (
-		id, _     = c.ParamsInt("id")
-		ctx, span = tracing.TraceStart(c.Context(), h.tracer, "DeleteUserHandler", trace.WithAttributes(attribute.String("handler", "DeleteUser"), attribute.Int("id", id)))
+		ctx, span = tracing.TraceStart(c.UserContext(), h.tracer, "DeleteUserHandler", trace.WithAttributes(attribute.String("handler", "DeleteUser"), attribute.Int("id", id)))
 	)
 
 	// Call service function
-	err := h.userService.DeleteUser(ctx, id)
+	err = h.userService.DeleteUser(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -164,3 +206,12 @@ func (h handler) DeleteUser(c *fiber.Ctx) error {
 		"message": "OK",
 	})
 }
+
+// userID return the id path parameter, a 400 when it is not a positive integer
+func userID(c *fiber.Ctx) (int, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "id must be a positive integer")
+	}
+	return id, nil
+}
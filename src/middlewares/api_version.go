@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAPIVersion is the request header selecting the API version of an
+// unversioned path, e.g. "2" or "v2", and the response header reporting the
+// version that served it
+const HeaderAPIVersion = "API-Version"
+
+// APIVersionConfig is the configuration of the APIVersion middleware
+type APIVersionConfig struct {
+	// Supported versions, the path prefixes of the versioned route groups,
+	// e.g. v1, v2
+	Versions []string
+}
+
+// APIVersion is the constructor function for the API version negotiation
+// middleware. A request to a versioned path, e.g. /v2/users, is served by
+// that version. A request to an unversioned path with an API-Version header
+// is routed to that version, /users with "API-Version: 2" is served as
+// /v2/users. It is registered before the versioned and unversioned routes,
+// the rewritten path is matched against the routes that follow it.
+func APIVersion(versionConfig *APIVersionConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		if versionConfig.supported(segment) {
+			c.Set(HeaderAPIVersion, segment)
+			return c.Next()
+		}
+
+		value := c.Get(HeaderAPIVersion)
+		if value == "" {
+			return c.Next()
+		}
+		version := normalizeVersion(value)
+		if !versionConfig.supported(version) {
+			return fiber.NewError(fiber.StatusBadRequest, "unsupported API version "+value+", supported: "+strings.Join(versionConfig.Versions, ", "))
+		}
+
+		c.Set(HeaderAPIVersion, version)
+		c.Path("/" + version + path)
+		return c.Next()
+	}
+}
+
+// normalizeVersion return the path segment of an API-Version header value,
+// "2" and "V2" are v2
+func normalizeVersion(value string) string {
+	version := strings.ToLower(strings.TrimSpace(value))
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
+
+func (a *APIVersionConfig) supported(version string) bool {
+	for _, v := range a.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
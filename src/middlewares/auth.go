@@ -1,76 +1,245 @@
 package middlewares
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"errors"
-	"log"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthProtected(c *fiber.Ctx) error {
-	return authentication(c)
+// verificationKey is the c.Locals key of the bearer token verification of the
+// request
+const verificationKey = "auth.verification"
+
+type (
+	authenticator struct {
+		config *AuthConfig
+		parser *jwt.Parser
+		// Verification settings, the authenticators with the same settings
+		// share the verification of a request
+		settings string
+
+		// Parsed public keys, read again from the key sources after KeysTTL
+		// or a RefreshKeys of the config
+		keysMu         sync.Mutex
+		keys           []crypto.PublicKey
+		keysLoaded     time.Time
+		keysGeneration uint64
+	}
+	// verification is the outcome of the bearer token verification, kept for
+	// the other middlewares of the request
+	verification struct {
+		settings  string
+		principal *Principal
+		err       error
+	}
+)
+
+// AuthProtected is the constructor function for the JWT authentication
+// middleware, the Principal of the verified token is set in c.Locals (see
+// GetPrincipal) for Authorize and the handlers. With AuthConfig.Users, the
+// subject is the ID of a user loaded in c.Locals and c.UserContext() (see
+// GetUser).
+func AuthProtected(authConfig *AuthConfig) fiber.Handler {
+	return newAuthenticator(authConfig).authentication
+}
+
+func newAuthenticator(authConfig *AuthConfig) *authenticator {
+	return &authenticator{
+		config: authConfig,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(authConfig.Algorithms),
+			jwt.WithLeeway(authConfig.Leeway),
+			jwt.WithIssuedAt(),
+		),
+		settings: fmt.Sprintf("%q %q %q %s %v %q %q",
+			authConfig.Issuer, authConfig.Audiences, authConfig.Algorithms, authConfig.Leeway,
+			authConfig.KeySources, authConfig.ScopeClaim, authConfig.RolesClaim),
+	}
 }
 
-func authentication(c *fiber.Ctx) error {
-	var (
-		key         *rsa.PublicKey
-		bearerToken string
-		jwtToken    string
-		err         error
-	)
+func (a *authenticator) authentication(c *fiber.Ctx) error {
+	principal, err := a.principal(c)
+	if err != nil {
+		return err
+	}
+	c.Locals(PrincipalKey, principal)
+	c.SetUserContext(NewPrincipalContext(c.UserContext(), principal))
 
-	if c.Get("Authorization") != "" {
-		bearerToken = c.Get("Authorization")
-	} else if c.Get("authorization") != "" {
-		bearerToken = c.Get("authorization")
-	} else {
-		return c.SendStatus(fiber.StatusUnauthorized)
+	// Load the user of the subject, the tokens of unknown or deleted users are rejected
+	if a.config.Users != nil {
+		user, err := a.user(c.UserContext(), principal)
+		if err != nil {
+			return err
+		}
+		c.Locals(UserKey, user)
+		c.SetUserContext(models.NewUserContext(c.UserContext(), user))
+	}
+
+	return c.Next()
+}
+
+// principal return the principal of the verified bearer token of the request.
+// The token is verified once per request, the rate limiter and AuthProtected
+// share the outcome when their settings are the same.
+func (a *authenticator) principal(c *fiber.Ctx) (*Principal, error) {
+	if v, ok := c.Locals(verificationKey).(*verification); ok && v.settings == a.settings {
+		return v.principal, v.err
+	}
+
+	principal, err := a.verifyBearer(c.UserContext(), c.Get(fiber.HeaderAuthorization))
+	c.Locals(verificationKey, &verification{settings: a.settings, principal: principal, err: err})
+	return principal, err
+}
+
+// verifyBearer verify the bearer token of an Authorization header or metadata,
+// the errors are the responses of AuthProtected
+func (a *authenticator) verifyBearer(ctx context.Context, bearerToken string) (*Principal, error) {
+	if bearerToken == "" {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "missing bearer token")
 	}
 
 	// Split the bearer token
 	split := strings.Split(bearerToken, " ")
 	if len(split) < 2 {
-		return c.SendStatus(utils.StatusInvalidToken)
+		return nil, fiber.NewError(utils.StatusInvalidToken, "malformed bearer token")
 	}
 
-	// Set JWT Token
-	jwtToken = split[1]
-
-	// Load the public key from environment variable
-	SecretKey := "-----BEGIN CERTIFICATE-----\n" + config.OAuthConfig.PublicKey + "\n-----END CERTIFICATE-----"
-
-	// Parse a certificate
-	key, err = jwt.ParseRSAPublicKeyFromPEM([]byte(SecretKey))
+	// Load the public keys
+	keys, err := a.publicKeys(ctx)
 	if err != nil {
 		// Invalid public key
-		log.Println("ParseRSAPublicKeyFromPEM Error:", err)
-		return c.SendStatus(fiber.StatusInternalServerError)
+		utils.Log(ctx, "AuthProtected public keys error:", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "token verification unavailable")
 	}
 
 	// Verify the signature
-	token, err := jwt.Parse(jwtToken, func(jwtToken *jwt.Token) (interface{}, error) {
-		if _, ok := jwtToken.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, errors.New("unexpected method: " + jwtToken.Header["alg"].(string))
-		}
-		return key, nil
-	})
+	token, err := a.verify(split[1], keys)
 	if err != nil {
-		// 401, Unexpected method token algorithm
-		return c.Status(fiber.StatusUnauthorized).JSON(err)
+		// 401, Unexpected method token algorithm, bad signature or invalid claims
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
-	// TODO: using claims data for get user from database
-	_, ok := token.Claims.(jwt.MapClaims)
+	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return c.Status(fiber.StatusUnauthorized).JSON(errors.New("invalid token"))
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid token")
+	}
+
+	return NewPrincipal(claims, a.config.ScopeClaim, a.config.RolesClaim), nil
+}
+
+// publicKeys return the cached public keys, they are read from the key
+// sources on first use, after KeysTTL and after a RefreshKeys of the config.
+// When the sources cannot be read again, e.g. halfway through a rotation, the
+// current keys are kept until the next attempt.
+func (a *authenticator) publicKeys(ctx context.Context) ([]crypto.PublicKey, error) {
+	a.keysMu.Lock()
+	defer a.keysMu.Unlock()
+
+	generation := a.config.keysGeneration.Load()
+	if a.keys != nil && generation == a.keysGeneration && time.Since(a.keysLoaded) < a.config.KeysTTL {
+		return a.keys, nil
+	}
+
+	keys, err := a.loadKeys()
+	if err != nil {
+		if a.keys == nil {
+			return nil, err
+		}
+		utils.Log(ctx, "AuthProtected public keys refresh error, keeping the current keys:", err)
+		keys = a.keys
+	}
+
+	a.keys, a.keysLoaded, a.keysGeneration = keys, time.Now(), generation
+	return a.keys, nil
+}
+
+// loadKeys read the public keys of every key source
+func (a *authenticator) loadKeys() ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for _, source := range a.config.KeySources {
+		sourceKeys, err := source.Keys()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, sourceKeys...)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no public key configured")
 	}
+	return keys, nil
+}
 
-	// TODO: Load user from database
+// verify parse the token with every key matching its signing method and check
+// the registered claims
+func (a *authenticator) verify(jwtToken string, keys []crypto.PublicKey) (*jwt.Token, error) {
+	err := errors.New("no public key matches the token signing method")
+	for _, key := range keys {
+		var token *jwt.Token
+		token, err = a.parser.Parse(jwtToken, func(token *jwt.Token) (interface{}, error) {
+			if !keyMatchesMethod(key, token.Method) {
+				return nil, errors.New("unexpected method: " + token.Method.Alg())
+			}
+			return key, nil
+		})
+		if err != nil {
+			continue
+		}
+
+		if err = a.checkClaims(token); err != nil {
+			return nil, err
+		}
+		return token, nil
+	}
+
+	return nil, err
+}
+
+func (a *authenticator) checkClaims(token *jwt.Token) error {
+	if a.config.Issuer != "" {
+		issuer, _ := token.Claims.GetIssuer()
+		if issuer != a.config.Issuer {
+			return jwt.ErrTokenInvalidIssuer
+		}
+	}
+
+	if len(a.config.Audiences) > 0 {
+		audiences, _ := token.Claims.GetAudience()
+		for _, audience := range audiences {
+			for _, accepted := range a.config.Audiences {
+				if audience == accepted {
+					return nil
+				}
+			}
+		}
+		return jwt.ErrTokenInvalidAudience
+	}
 
 	return nil
 }
+
+func keyMatchesMethod(key crypto.PublicKey, method jwt.SigningMethod) bool {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		_, ok := key.(*ecdsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodEd25519:
+		_, ok := key.(ed25519.PublicKey)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/repositories"
+)
+
+type (
+	// AuthConfig is the configuration of the AuthProtected middleware
+	AuthConfig struct {
+		// Expected "iss" claim, not checked when empty
+		Issuer string
+		// Accepted "aud" claims, the token must contain one of them, not checked when empty
+		Audiences []string
+		// Accepted signing algorithms, e.g. RS256
+		Algorithms []string
+		// Clock skew tolerated when checking "exp", "nbf" and "iat"
+		Leeway time.Duration
+		// Sources of the public keys used to verify token signatures
+		KeySources []KeySource
+		// Claims of the scopes and roles of the Principal, dotted for nested claims
+		ScopeClaim string
+		RolesClaim string
+		// Users of the token subjects, the users are not loaded when nil
+		Users repositories.UserRepository
+		// Cache of the loaded users, for UserCacheTTL
+		Cacher       cache.Cacher
+		UserCacheTTL time.Duration
+		// Time the public keys are cached before the key sources are read
+		// again, 0 reads them on every request
+		KeysTTL time.Duration
+
+		// Incremented by RefreshKeys, the authenticators read the key sources
+		// again when it changes
+		keysGeneration atomic.Uint64
+	}
+	// KeySource provide public keys used to verify token signatures
+	KeySource interface {
+		Keys() ([]crypto.PublicKey, error)
+	}
+
+	pemKeySource struct {
+		pem []byte
+	}
+	pemFileKeySource struct {
+		path string
+	}
+)
+
+// NewAuthConfig build the auth configuration from the OAuth config section
+func NewAuthConfig(config *config.Config) *AuthConfig {
+	authConfig := &AuthConfig{
+		Issuer:     config.OAuth.Issuer,
+		Audiences:  config.OAuth.Audiences,
+		Algorithms: config.OAuth.Algorithms,
+		Leeway:     config.OAuth.Leeway,
+		ScopeClaim: config.OAuth.ScopeClaim,
+		RolesClaim: config.OAuth.RolesClaim,
+
+		UserCacheTTL: config.OAuth.UserCacheTTL,
+		KeysTTL:      config.OAuth.KeysTTL,
+	}
+
+	if config.OAuth.PublicKey != "" {
+		authConfig.KeySources = append(authConfig.KeySources, PEMKeySource(config.OAuth.PublicKey))
+	}
+	for _, path := range config.OAuth.PublicKeyPaths {
+		authConfig.KeySources = append(authConfig.KeySources, PEMFileKeySource(path))
+	}
+
+	return authConfig
+}
+
+// RefreshKeys make the authenticators of the config read the key sources again
+// on their next request, e.g. after a reload
+func (a *AuthConfig) RefreshKeys() {
+	a.keysGeneration.Add(1)
+}
+
+// PEMKeySource read keys from PEM encoded certificates or public keys. A bare
+// base64 body without PEM header is read as a certificate.
+func PEMKeySource(value string) KeySource {
+	if !strings.Contains(value, "-----BEGIN") {
+		value = "-----BEGIN CERTIFICATE-----\n" + value + "\n-----END CERTIFICATE-----"
+	}
+	return pemKeySource{pem: []byte(value)}
+}
+
+// PEMFileKeySource read keys from a PEM file
+func PEMFileKeySource(path string) KeySource {
+	return pemFileKeySource{path: path}
+}
+
+func (s pemKeySource) Keys() ([]crypto.PublicKey, error) {
+	return parsePublicKeys(s.pem)
+}
+
+func (s pemFileKeySource) Keys() ([]crypto.PublicKey, error) {
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := parsePublicKeys(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return keys, nil
+}
+
+// parsePublicKeys parse every certificate or public key block of a PEM document
+func parsePublicKeys(content []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, cert.PublicKey)
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		case "RSA PUBLIC KEY":
+			key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no certificate or public key found in PEM")
+	}
+
+	return keys, nil
+}
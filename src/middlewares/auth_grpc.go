@@ -0,0 +1,131 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationMetadata is the metadata key of the bearer token, gRPC
+// lowercases them
+const authorizationMetadata = "authorization"
+
+// publicServices are served without bearer token, the load balancers and the
+// tooling call them anonymously
+var publicServices = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+// grpcAuthenticator authenticate and authorize the gRPC calls like
+// AuthProtected and Authorize do the HTTP requests
+type grpcAuthenticator struct {
+	*authenticator
+	// Policies by full method name
+	policies map[string]*Policy
+}
+
+// AuthUnaryServerInterceptor is the constructor function for the JWT
+// authentication of the unary gRPC calls, the bearer token is read from the
+// "authorization" metadata. Every call must carry a token allowed by the
+// policy of its method, keyed by full method name, e.g.
+// /user.v1.UserService/GetUser. The methods without policy are denied, the
+// health and reflection services are public. The principal and the user are
+// in the context of the handler (see PrincipalFromContext).
+func AuthUnaryServerInterceptor(authConfig *AuthConfig, policies map[string]*Policy) grpc.UnaryServerInterceptor {
+	a := &grpcAuthenticator{authenticator: newAuthenticator(authConfig), policies: policies}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamServerInterceptor is the constructor function for the JWT
+// authentication of the streaming gRPC calls, see AuthUnaryServerInterceptor
+func AuthStreamServerInterceptor(authConfig *AuthConfig, policies map[string]*Policy) grpc.StreamServerInterceptor {
+	a := &grpcAuthenticator{authenticator: newAuthenticator(authConfig), policies: policies}
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorize return the context of the call with its principal and user, the
+// errors are gRPC status errors
+func (a *grpcAuthenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var bearerToken string
+	if values := md.Get(authorizationMetadata); len(values) > 0 {
+		bearerToken = values[0]
+	}
+	principal, err := a.verifyBearer(ctx, bearerToken)
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+	ctx = NewPrincipalContext(ctx, principal)
+
+	if a.config.Users != nil {
+		user, err := a.user(ctx, principal)
+		if err != nil {
+			return nil, grpcStatus(err)
+		}
+		ctx = models.NewUserContext(ctx, user)
+	}
+
+	policy, ok := a.policies[method]
+	if !ok {
+		utils.Logf(ctx, "Authorize denied %s to %q: no policy", method, principal.Subject)
+		return nil, status.Error(codes.PermissionDenied, "forbidden: no policy")
+	}
+	if reason := policy.deny(nil, principal); reason != "" {
+		utils.Logf(ctx, "Authorize denied %s to %q: %s", method, principal.Subject, reason)
+		return nil, status.Error(codes.PermissionDenied, "forbidden: "+reason)
+	}
+
+	return ctx, nil
+}
+
+// grpcStatus return the gRPC status of an AuthProtected response
+func grpcStatus(err error) error {
+	var fiberErr *fiber.Error
+	if !errors.As(err, &fiberErr) {
+		return status.Error(codes.Internal, "authentication failed")
+	}
+	switch fiberErr.Code {
+	case fiber.StatusUnauthorized, utils.StatusInvalidToken:
+		return status.Error(codes.Unauthenticated, fiberErr.Message)
+	case fiber.StatusForbidden:
+		return status.Error(codes.PermissionDenied, fiberErr.Message)
+	}
+	return status.Error(codes.Internal, fiberErr.Message)
+}
+
+// serverStream override the context of a stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// UserKey is the c.Locals key of the user of the bearer token
+const UserKey = "user"
+
+// errUnknownUser is a token subject without user, or a deleted one
+var errUnknownUser = errors.New("unknown user")
+
+// cachedUser is a user in the cache, it expires after UserCacheTTL whatever
+// the expiration of the cache
+type cachedUser struct {
+	User    models.User `json:"user"`
+	Expires time.Time   `json:"expires"`
+}
+
+// GetUser return the user of the bearer token, nil when AuthProtected does
+// not load the users. The services read it from the context with
+// models.UserFromContext.
+func GetUser(c *fiber.Ctx) *models.User {
+	user, _ := c.Locals(UserKey).(*models.User)
+	return user
+}
+
+// userCacheKey is the cache key of the user of a token subject, the entries
+// are tagged "users" so the user writes flush them
+func userCacheKey(subject string) string {
+	return "auth:user:" + subject
+}
+
+// user return the user of the principal, the errors are the responses of
+// AuthProtected
+func (a *authenticator) user(ctx context.Context, principal *Principal) (*models.User, error) {
+	user, err := a.loadUser(ctx, principal.Subject)
+	if errors.Is(err, errUnknownUser) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unknown user")
+	}
+	if err != nil {
+		utils.Log(ctx, "AuthProtected user error:", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "user lookup failed")
+	}
+	return user, nil
+}
+
+// loadUser return the user of the token subject, its ID. A cached user is
+// used until it expires.
+func (a *authenticator) loadUser(ctx context.Context, subject string) (*models.User, error) {
+	id, err := strconv.Atoi(subject)
+	if err != nil || id <= 0 {
+		return nil, errUnknownUser
+	}
+
+	cacheable := a.config.Cacher != nil && a.config.UserCacheTTL > 0
+	if cacheable {
+		var cached cachedUser
+		if err := a.config.Cacher.Get(ctx, userCacheKey(subject), &cached); err == nil && time.Now().Before(cached.Expires) {
+			return &cached.User, nil
+		}
+	}
+
+	user, err := a.config.Users.GetUserByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errUnknownUser
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt.Valid {
+		return nil, errUnknownUser
+	}
+
+	if cacheable {
+		// A cache failure only costs a query on the next request
+		_ = a.config.Cacher.Tag("users").Set(ctx, userCacheKey(subject), cachedUser{User: user, Expires: time.Now().Add(a.config.UserCacheTTL)})
+	}
+	return &user, nil
+}
@@ -0,0 +1,94 @@
+package middlewares
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Policy is the access rule of a route or a group, every condition must hold
+type Policy struct {
+	// Scopes the token must all grant
+	Scopes []string
+	// Roles of which the principal must have one
+	Roles []string
+	// Claims the token must carry, by dotted name, with one of the values
+	Claims map[string][]string
+	// Custom rule, its error is the reason of the denial. c is nil for the
+	// gRPC calls, the principal is the one of the call.
+	Check func(c *fiber.Ctx, principal *Principal) error
+}
+
+// Authorize is the constructor function for the authorization middleware, it
+// enforces the policy on the principal set by AuthProtected, which must run
+// first. A request without principal gets a 401, a denied one a 403 with the
+// reason.
+func Authorize(policy *Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "authentication required")
+		}
+
+		if reason := policy.deny(c, principal); reason != "" {
+			utils.Logf(c.UserContext(), "Authorize denied %s %s to %q: %s", c.Method(), c.Path(), principal.Subject, reason)
+			return fiber.NewError(fiber.StatusForbidden, "forbidden: "+reason)
+		}
+
+		return c.Next()
+	}
+}
+
+// deny return why the principal is denied, empty when it is allowed
+func (p *Policy) deny(c *fiber.Ctx, principal *Principal) string {
+	for _, scope := range p.Scopes {
+		if !principal.HasScope(scope) {
+			return "missing scope " + scope
+		}
+	}
+
+	if len(p.Roles) > 0 {
+		allowed := false
+		for _, role := range p.Roles {
+			if principal.HasRole(role) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "requires one of the roles " + strings.Join(p.Roles, ", ")
+		}
+	}
+
+	// Sorted so the reason does not depend on the map order
+	names := make([]string, 0, len(p.Claims))
+	for name := range p.Claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, _ := principal.Claim(name)
+		if !containsAny(claimValues(value), p.Claims[name]) {
+			return "claim " + name + " must be one of " + strings.Join(p.Claims[name], ", ")
+		}
+	}
+
+	if p.Check != nil {
+		if err := p.Check(c, principal); err != nil {
+			return err.Error()
+		}
+	}
+
+	return ""
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/certs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientIdentityKey is the c.Locals key of the verified client certificate identity
+const ClientIdentityKey = "clientIdentity"
+
+// ClientCertificate store the identity of the verified mTLS client certificate
+// in c.Locals, requests without a client certificate are passed through
+func ClientCertificate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if identity := certs.VerifiedClientIdentity(c.Context().TLSConnectionState()); identity != nil {
+			c.Locals(ClientIdentityKey, identity)
+		}
+		return c.Next()
+	}
+}
+
+// ClientIdentity return the verified client certificate identity of the
+// request, nil when the client did not present one
+func ClientIdentity(c *fiber.Ctx) *certs.ClientIdentity {
+	identity, _ := c.Locals(ClientIdentityKey).(*certs.ClientIdentity)
+	return identity
+}
@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"net"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/clientip"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientIPKey is the c.Locals key of the resolved client IP, read by the
+// logger format as ${locals:clientip}
+const ClientIPKey = "clientip"
+
+// NewClientIPResolver build the client IP resolver from the network config
+// section
+func NewClientIPResolver(config *config.Config) *clientip.Resolver {
+	return clientip.NewResolver(config.Network.TrustedNetworks(), config.Network.ProxyHeader)
+}
+
+// ClientIP is the constructor function for the client IP middleware. Requests
+// from trusted proxies get the client IP of the PROXY_HEADER chain, the others
+// the address of the connection. Read it with RealIP(c).
+func ClientIP(resolver *clientip.Resolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var values []string
+		for _, value := range c.Request().Header.PeekAll(resolver.Header()) {
+			values = append(values, string(value))
+		}
+		ip := resolver.Resolve(c.Context().RemoteIP(), values)
+		c.Locals(ClientIPKey, ip.String())
+		return c.Next()
+	}
+}
+
+// RealIP return the resolved client IP of the request, the address of the
+// connection when the ClientIP middleware did not run
+func RealIP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals(ClientIPKey).(string); ok {
+		return ip
+	}
+	return c.IP()
+}
+
+// IPFilterConfig is the configuration of the IPFilter middleware
+type IPFilterConfig struct {
+	// Skip the middleware when it returns true
+	Next func(c *fiber.Ctx) bool
+	// Client networks allowed, every client when empty
+	Allow []*net.IPNet
+	// Client networks refused, they take precedence over Allow
+	Deny []*net.IPNet
+	// Client networks allowed under a path, on top of Allow. Every group
+	// matching the request path applies.
+	AllowGroups []*config.GroupNetworks
+	// Client networks refused under a path, they take precedence over Allow
+	// and AllowGroups
+	DenyGroups []*config.GroupNetworks
+}
+
+// IPFilter is the constructor function for the IP allow/deny list middleware,
+// use it on a route group, e.g. s.Group("/internal", middlewares.IPFilter(...)).
+// Refused clients get a 403.
+func IPFilter(filterConfig *IPFilterConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if filterConfig.Next != nil && filterConfig.Next(c) {
+			return c.Next()
+		}
+		if len(filterConfig.Allow) == 0 && len(filterConfig.Deny) == 0 &&
+			len(filterConfig.AllowGroups) == 0 && len(filterConfig.DenyGroups) == 0 {
+			return c.Next()
+		}
+
+		ip := net.ParseIP(RealIP(c))
+		if clientip.Contains(filterConfig.Deny, ip) ||
+			len(filterConfig.Allow) > 0 && !clientip.Contains(filterConfig.Allow, ip) {
+			return fiber.NewError(fiber.StatusForbidden, "client IP not allowed")
+		}
+		for _, group := range filterConfig.DenyGroups {
+			if matchRequestPath(group.Path, c) && clientip.Contains(group.Networks, ip) {
+				return fiber.NewError(fiber.StatusForbidden, "client IP not allowed")
+			}
+		}
+		for _, group := range filterConfig.AllowGroups {
+			if matchRequestPath(group.Path, c) && !clientip.Contains(group.Networks, ip) {
+				return fiber.NewError(fiber.StatusForbidden, "client IP not allowed")
+			}
+		}
+		return c.Next()
+	}
+}
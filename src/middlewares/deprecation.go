@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeprecationConfig is the configuration of the Deprecated middleware
+type DeprecationConfig struct {
+	// Date the route was deprecated
+	Since time.Time
+	// Date the route is removed, optional
+	Sunset time.Time
+	// Prefix of the successor of the route, the successor of /users with the
+	// prefix /v1 is /v1/users. Optional.
+	Successor string
+	// Documentation of the deprecation, optional
+	Link string
+}
+
+// Deprecated is the constructor function for the deprecated route middleware,
+// it is registered with the handler of the route. The responses carry the
+// Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers pointing to the
+// successor, and the requests are counted by the
+// http_deprecated_requests_total metric to follow the clients still using it.
+func Deprecated(deprecationConfig *DeprecationConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "@"+strconv.FormatInt(deprecationConfig.Since.Unix(), 10))
+		if !deprecationConfig.Sunset.IsZero() {
+			c.Set("Sunset", deprecationConfig.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if deprecationConfig.Successor != "" {
+			c.Append(fiber.HeaderLink, `<`+deprecationConfig.Successor+c.Path()+`>; rel="successor-version"`)
+		}
+		if deprecationConfig.Link != "" {
+			c.Append(fiber.HeaderLink, `<`+deprecationConfig.Link+`>; rel="deprecation"; type="text/html"`)
+		}
+
+		metrics.DeprecatedRequests.WithLabelValues(c.Method(), c.Route().Path).Inc()
+		return c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics count the HTTP requests and measure their duration, requests are
+// labelled with the route pattern (/users/:id) to keep the cardinality low
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		route := c.Route().Path
+		if status == fiber.StatusNotFound {
+			route = "unmatched"
+		}
+		metrics.HttpRequests.WithLabelValues(c.Method(), route, strconv.Itoa(status)).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,118 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PrincipalKey is the c.Locals key of the Principal authenticated by
+// AuthProtected
+const PrincipalKey = "principal"
+
+type principalContextKey struct{}
+
+// Principal is the client authenticated by a bearer token
+type Principal struct {
+	// "sub" claim
+	Subject string
+	// Scopes granted by the token
+	Scopes []string
+	// Roles of the client
+	Roles []string
+	// Claims of the verified token, for the custom claims
+	Claims jwt.MapClaims
+}
+
+// NewPrincipal build the principal of verified claims, the scopes and roles
+// are read from the scopeClaim and rolesClaim claims
+func NewPrincipal(claims jwt.MapClaims, scopeClaim string, rolesClaim string) *Principal {
+	principal := &Principal{Claims: claims}
+	principal.Subject, _ = claims.GetSubject()
+	if value, ok := principal.Claim(scopeClaim); ok {
+		principal.Scopes = claimValues(value)
+	}
+	if value, ok := principal.Claim(rolesClaim); ok {
+		principal.Roles = claimValues(value)
+	}
+	return principal
+}
+
+// GetPrincipal return the principal of the request, nil when it did not go
+// through AuthProtected
+func GetPrincipal(c *fiber.Ctx) *Principal {
+	principal, _ := c.Locals(PrincipalKey).(*Principal)
+	return principal
+}
+
+// NewPrincipalContext return a copy of ctx carrying the principal
+func NewPrincipalContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext return the principal of ctx, nil for anonymous
+// requests
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+// HasScope report whether the token grants the scope
+func (p *Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+// HasRole report whether the principal has the role
+func (p *Principal) HasRole(role string) bool {
+	return contains(p.Roles, role)
+}
+
+// Claim return the value of a claim, nested claims are dotted, e.g.
+// realm_access.roles
+func (p *Principal) Claim(name string) (interface{}, bool) {
+	var value interface{} = map[string]interface{}(p.Claims)
+	for _, key := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// claimValues return the strings of a claim, a list or a space separated
+// string. Booleans and numbers are formatted, e.g. "true".
+func claimValues(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []string:
+		return value
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case bool, float64:
+		return []string{fmt.Sprint(value)}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/metrics"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/ratelimit"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAPIKey is the request header carrying the API key of a client
+const HeaderAPIKey = "X-API-Key"
+
+type (
+	// RateLimitConfig is the configuration of the RateLimit middleware
+	RateLimitConfig struct {
+		// Skip the middleware when it returns true
+		Next func(c *fiber.Ctx) bool
+		// Length of the sliding window the limits apply to
+		Window time.Duration
+		// Requests per window of the anonymous clients, limited per IP
+		AnonymousLimit int
+		// Requests per window of each tier
+		Tiers map[string]int
+		// Tier of the tokens without tier claim
+		DefaultTier string
+		// JWT claim holding the tier of the subject, dotted for nested claims
+		TierClaim string
+		// Tier of each API key
+		APIKeys map[string]string
+		// Route costs, the first match applies, other requests cost 1
+		Costs []*config.RouteCost
+		// Verify the bearer tokens, anonymous when nil
+		Auth *AuthConfig
+		// Counters, shared by the processes when backed by Redis
+		Storage fiber.Storage
+		// Key of the signed X-RateLimit-Bypass header, disabled when empty
+		BypassSecret string
+		// Longest lifetime accepted for a signed bypass header
+		BypassMaxTTL time.Duration
+		// Client networks that are not rate limited
+		BypassNetworks []*net.IPNet
+	}
+
+	rateLimiter struct {
+		config  *RateLimitConfig
+		auth    *authenticator
+		window  *ratelimit.SlidingWindow
+		apiKeys map[string]string
+	}
+)
+
+// NewRateLimitConfig build the rate limit configuration from the rate_limit
+// and oauth config sections
+func NewRateLimitConfig(config *config.Config, storage fiber.Storage) *RateLimitConfig {
+	return &RateLimitConfig{
+		Window:         config.RateLimit.RateLimitWindow,
+		AnonymousLimit: config.Fiber.RateLimit,
+		Tiers:          config.RateLimit.Tiers(),
+		DefaultTier:    config.RateLimit.RateLimitDefaultTier,
+		TierClaim:      config.RateLimit.RateLimitTierClaim,
+		APIKeys:        config.RateLimit.APIKeys(),
+		Costs:          config.RateLimit.Costs(),
+		Auth:           NewAuthConfig(config),
+		Storage:        storage,
+		BypassSecret:   config.RateLimit.RateLimitBypassSecret.Value(),
+		BypassMaxTTL:   config.RateLimit.RateLimitBypassMaxTTL,
+		BypassNetworks: config.RateLimit.BypassNetworks(),
+	}
+}
+
+// RateLimit is the constructor function for the rate limit middleware. The
+// requests are counted per API key, per JWT subject or per IP for anonymous
+// clients, over a sliding window.
+func RateLimit(rateLimitConfig *RateLimitConfig) fiber.Handler {
+	r := &rateLimiter{
+		config:  rateLimitConfig,
+		window:  ratelimit.NewSlidingWindow(ratelimit.StorageCounter(rateLimitConfig.Storage), rateLimitConfig.Window),
+		apiKeys: map[string]string{},
+	}
+	if rateLimitConfig.Auth != nil && len(rateLimitConfig.Auth.KeySources) > 0 {
+		r.auth = newAuthenticator(rateLimitConfig.Auth)
+	}
+	// The counters are keyed by a hash, the API keys do not end up in Redis
+	for key, tier := range rateLimitConfig.APIKeys {
+		r.apiKeys[hashAPIKey(key)] = tier
+	}
+
+	return r.limit
+}
+
+func (r *rateLimiter) limit(c *fiber.Ctx) error {
+	if r.config.Next != nil && r.config.Next(c) {
+		return c.Next()
+	}
+
+	// Every bypass is logged and counted, they are meant for load tests only
+	mechanism, err := r.bypass(c)
+	if err != nil {
+		utils.Logf(c.UserContext(), "RateLimit bypass rejected (%v): %s %s from %s", err, c.Method(), c.Path(), RealIP(c))
+	}
+	if mechanism != "" {
+		utils.Logf(c.UserContext(), "RateLimit bypass (%s): %s %s from %s", mechanism, c.Method(), c.Path(), RealIP(c))
+		metrics.RateLimitBypasses.WithLabelValues(mechanism).Inc()
+		return c.Next()
+	}
+
+	key, limit := r.principal(c)
+	result, err := r.window.Take(key, limit, r.cost(c))
+	if err != nil {
+		// Do not turn a storage failure into an outage
+		utils.Log(c.UserContext(), "RateLimit storage error:", err)
+		return c.Next()
+	}
+
+	c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Set("RateLimit-Reset", seconds(result.Reset))
+	if !result.Allowed {
+		c.Set(fiber.HeaderRetryAfter, seconds(result.RetryAfter))
+		return fiber.NewError(fiber.StatusTooManyRequests, "rate limit exceeded")
+	}
+
+	return c.Next()
+}
+
+// principal return the counter key and the limit of the request client
+func (r *rateLimiter) principal(c *fiber.Ctx) (string, int) {
+	if apiKey := c.Get(HeaderAPIKey); apiKey != "" {
+		hash := hashAPIKey(apiKey)
+		if tier, ok := r.apiKeys[hash]; ok {
+			return "apikey:" + hash[:16], r.tierLimit(tier)
+		}
+	}
+
+	if r.auth != nil && c.Get(fiber.HeaderAuthorization) != "" {
+		if principal, err := r.auth.principal(c); err == nil && principal.Subject != "" {
+			return "sub:" + principal.Subject, r.tierLimit(r.tier(principal))
+		}
+	}
+
+	return "ip:" + RealIP(c), r.config.AnonymousLimit
+}
+
+// tier return the tier claim of the principal, empty when it has none
+func (r *rateLimiter) tier(principal *Principal) string {
+	value, _ := principal.Claim(r.config.TierClaim)
+	if tiers := claimValues(value); len(tiers) > 0 {
+		return tiers[0]
+	}
+	return ""
+}
+
+// tierLimit return the limit of the tier, the default tier is used for
+// unknown tiers
+func (r *rateLimiter) tierLimit(tier string) int {
+	if limit, ok := r.config.Tiers[tier]; ok {
+		return limit
+	}
+	return r.config.Tiers[r.config.DefaultTier]
+}
+
+// cost return the cost of the first route cost matching the request
+func (r *rateLimiter) cost(c *fiber.Ctx) int {
+	for _, cost := range r.config.Costs {
+		if matchRoute(cost.Route, c) {
+			return cost.Cost
+		}
+	}
+	return 1
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// seconds format a duration as whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
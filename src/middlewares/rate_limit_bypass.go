@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/clientip"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderRateLimitBypass is the request header carrying a signed rate limit
+// bypass, "<expires unix>.<hex HMAC-SHA256 of expires, method and path>"
+const HeaderRateLimitBypass = "X-RateLimit-Bypass"
+
+// Rate limit bypass mechanisms, the label of the bypass metric
+const (
+	bypassSigned = "signed"
+	bypassCIDR   = "cidr"
+)
+
+// SignRateLimitBypass return a X-RateLimit-Bypass header value valid until
+// expires for the requests with the method and path, for load test tooling
+func SignRateLimitBypass(secret string, method string, path string, expires time.Time) string {
+	payload := strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + hex.EncodeToString(bypassSignature(secret, payload, method, path))
+}
+
+// bypass return the mechanism letting the request skip the rate limiter, empty
+// when it is limited. A bypass header that cannot be verified is returned as
+// an error.
+func (r *rateLimiter) bypass(c *fiber.Ctx) (string, error) {
+	if clientip.Contains(r.config.BypassNetworks, net.ParseIP(RealIP(c))) {
+		return bypassCIDR, nil
+	}
+
+	if r.config.BypassSecret == "" {
+		return "", nil
+	}
+	value := c.Get(HeaderRateLimitBypass)
+	if value == "" {
+		return "", nil
+	}
+	if err := r.verifyBypass(value, c.Method(), c.Path()); err != nil {
+		return "", err
+	}
+	return bypassSigned, nil
+}
+
+// verifyBypass check the signature and the expiration of a bypass header. The
+// header is only valid for the signed method and path, and the expiration
+// cannot be further than BypassMaxTTL so a leaked header is short lived.
+func (r *rateLimiter) verifyBypass(value string, method string, path string) error {
+	payload, signature, ok := strings.Cut(value, ".")
+	expires, err := strconv.ParseInt(payload, 10, 64)
+	if !ok || err != nil {
+		return errors.New("malformed header")
+	}
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(decoded, bypassSignature(r.config.BypassSecret, payload, method, path)) {
+		return errors.New("invalid signature")
+	}
+
+	remaining := time.Until(time.Unix(expires, 0))
+	if remaining <= 0 {
+		return errors.New("expired")
+	}
+	if remaining > r.config.BypassMaxTTL {
+		return errors.New("expires later than RATE_LIMIT_BYPASS_MAX_TTL")
+	}
+	return nil
+}
+
+// bypassSignature sign "<expires>\n<method>\n<path>"
+func bypassSignature(secret string, expires string, method string, path string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(expires + "\n" + method + "\n" + path))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/requestid"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/tracing"
+	"github.com/getsentry/sentry-go"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestIDKey is the c.Locals key of the request ID, read by the logger
+// format as ${locals:requestid}
+const RequestIDKey = "requestid"
+
+// RequestID is the constructor function for the request ID middleware. The ID
+// is the X-Request-ID of the client, else the trace ID of its traceparent,
+// else a new one. It is echoed in the X-Request-ID response header and put in
+// c.UserContext() with the trace of the caller and a Sentry hub tagged with
+// it, so the logs, spans and events of the request carry it.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := requestid.Resolve(c.Get(requestid.Header), c.Get("traceparent"))
+		c.Locals(RequestIDKey, id)
+		c.Set(requestid.Header, id)
+
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetTag("request_id", id)
+
+		ctx := requestid.NewContext(c.UserContext(), id)
+		ctx = tracing.Extract(ctx, c)
+		ctx = sentry.SetHubOnContext(ctx, hub)
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// matchRoute match the request against a configured route. It runs before
+// the router and APIVersion, so the path is matched segment by segment rather
+// than with c.Route(), in its versioned and unversioned forms (see
+// routePaths).
+func matchRoute(route config.Route, c *fiber.Ctx) bool {
+	if route.Method != c.Method() {
+		return false
+	}
+	if route.Query != "" && c.Query(route.Query) == "" {
+		return false
+	}
+	return matchRequestPath(route.Path, c)
+}
+
+// matchRequestPath match the path of the request against a route path, in its
+// versioned and unversioned forms
+func matchRequestPath(route string, c *fiber.Ctx) bool {
+	for _, path := range routePaths(c) {
+		if matchPath(route, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// routePaths return the path of the request and its other API version form,
+// /v2/users/1 is also /users/1 and /users/1 with "API-Version: 2" is also
+// /v2/users/1, so "/users/:id" applies to every version and "/v2/users/:id"
+// to the version 2 only
+func routePaths(c *fiber.Ctx) []string {
+	path := c.Path()
+	segment, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if isVersion(segment) {
+		return []string{path, "/" + rest}
+	}
+	if value := c.Get(HeaderAPIVersion); value != "" {
+		if version := normalizeVersion(value); isVersion(version) {
+			return []string{path, "/" + version + path}
+		}
+	}
+	return []string{path}
+}
+
+// isVersion report whether a path segment is an API version, e.g. v2
+func isVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	for _, r := range segment[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPath match a path against a route path, ":name" matches a single
+// segment and "*" the rest of the path
+func matchPath(route string, path string) bool {
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range routeSegments {
+		if segment == "*" {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if !strings.HasPrefix(segment, ":") && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(routeSegments) == len(pathSegments)
+}
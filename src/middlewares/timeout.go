@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TimeoutConfig is the configuration of the Timeout middleware
+type TimeoutConfig struct {
+	// Deadline of the requests, 0 disables it
+	Timeout time.Duration
+	// Deadline of the matching requests, the first match applies
+	Routes []*config.RouteTimeout
+}
+
+// NewTimeoutConfig build the timeout configuration from the request config
+// section
+func NewTimeoutConfig(config *config.Config) *TimeoutConfig {
+	return &TimeoutConfig{
+		Timeout: config.Request.RequestTimeout,
+		Routes:  config.Request.RouteTimeouts(),
+	}
+}
+
+// Timeout is the constructor function for the request timeout middleware. It
+// sets a context with a deadline as the Fiber UserContext, handlers pass
+// c.UserContext() down so the queries are cancelled when it expires. A handler
+// that fails after the deadline gets a 504.
+func Timeout(timeoutConfig *TimeoutConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timeout := timeoutConfig.timeout(c)
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fiber.NewError(fiber.StatusGatewayTimeout, "request timeout after "+timeout.String())
+		}
+		return err
+	}
+}
+
+// timeout return the deadline of the first route matching the request
+func (t *TimeoutConfig) timeout(c *fiber.Ctx) time.Duration {
+	for _, route := range t.Routes {
+		if matchRoute(route.Route, c) {
+			return route.Timeout
+		}
+	}
+	return t.Timeout
+}
@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/openapi"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ValidationConfig is the configuration of the Validation middleware
+type ValidationConfig struct {
+	// Registry of the routes, its document is the contract
+	Registry *openapi.Registry
+	// Reject the requests that do not match the document
+	Requests bool
+	// Replace the responses that do not match the document by a 500, for the
+	// tests
+	Responses bool
+	// Leave the operations with a security requirement to the Validation of
+	// their routes, registered after the authentication so that the
+	// unauthenticated requests get a 401 rather than the violations
+	SkipSecured bool
+}
+
+// NewValidationConfig build the validation configuration from the openapi
+// config section
+func NewValidationConfig(config *config.Config, registry *openapi.Registry) *ValidationConfig {
+	return &ValidationConfig{
+		Registry:  registry,
+		Requests:  config.OpenAPI.OpenAPIValidateRequests,
+		Responses: config.OpenAPI.OpenAPIValidateResponses,
+	}
+}
+
+// Validation is the constructor function for the OpenAPI validation
+// middleware. The path, query and header parameters and the body of the
+// documented requests are checked against their operation, every violation
+// is listed in a 400 (415 for a body that is not JSON). It is registered after
+// the APIVersion middleware so the versioned path is matched, and after the
+// authentication on the protected routes (see SkipSecured). Requests that are
+// not documented are left to the router.
+func Validation(validationConfig *ValidationConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !validationConfig.Requests && !validationConfig.Responses {
+			return c.Next()
+		}
+
+		validator := validationConfig.Registry.Validator()
+		operation, params := validator.Find(c.Method(), c.Path())
+		if operation == nil || validationConfig.SkipSecured && len(operation.Security) > 0 {
+			return c.Next()
+		}
+
+		if validationConfig.Requests {
+			if err := validateRequest(c, validator, operation, params); err != nil {
+				return err
+			}
+		}
+
+		err := c.Next()
+		if err != nil || !validationConfig.Responses {
+			return err
+		}
+
+		response := c.Response()
+		violations := validator.Response(operation, response.StatusCode(), string(response.Header.ContentType()), response.Body())
+		if len(violations) > 0 {
+			utils.Logf(c.UserContext(), "Response of %s %s does not match the OpenAPI document: %+v", c.Method(), c.Path(), violations)
+			return &openapi.ValidationError{
+				Code:       fiber.StatusInternalServerError,
+				Message:    "response does not match the OpenAPI document",
+				Violations: violations,
+			}
+		}
+		return nil
+	}
+}
+
+func validateRequest(c *fiber.Ctx, validator *openapi.Validator, operation *openapi.Operation, params map[string]string) error {
+	var violations []openapi.Violation
+	for _, parameter := range operation.Parameters {
+		var (
+			value   string
+			present bool
+		)
+		switch parameter.In {
+		case "path":
+			value, present = params[parameter.Name]
+		case "query":
+			value, present = c.Query(parameter.Name), c.Context().QueryArgs().Has(parameter.Name)
+		case "header":
+			value = c.Get(parameter.Name)
+			present = value != ""
+		default:
+			continue
+		}
+		violations = append(violations, validator.Parameter(parameter, value, present)...)
+	}
+
+	if operation.RequestBody != nil {
+		bodyViolations := validator.Body(operation.RequestBody, c.Get(fiber.HeaderContentType), c.Body())
+		if len(bodyViolations) == 1 && bodyViolations[0].Name == fiber.HeaderContentType {
+			return &openapi.ValidationError{
+				Code:       fiber.StatusUnsupportedMediaType,
+				Message:    "unsupported content type",
+				Violations: append(violations, bodyViolations...),
+			}
+		}
+		violations = append(violations, bodyViolations...)
+	}
+
+	if len(violations) > 0 {
+		return &openapi.ValidationError{
+			Code:       fiber.StatusBadRequest,
+			Message:    "request does not match the OpenAPI document",
+			Violations: violations,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package models
+
+import "context"
+
+type userContextKey struct{}
+
+// NewUserContext return a copy of ctx carrying the authenticated user
+func NewUserContext(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext return the authenticated user of ctx, nil for anonymous
+// requests
+func UserFromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(userContextKey{}).(*User)
+	return user
+}
@@ -22,12 +22,10 @@ func NewDbRepository(db *gorm.DB, tracer trace.Tracer) DbRepository {
 }
 
 func (r dbRepository) CheckDatabaseConnection(ctx context.Context) error {
-	var (
-		_, childSpan = tracing.TraceStart(ctx, r.tracer, "CheckDatabaseConnectionHandler", trace.WithAttributes(attribute.String("handler", "CheckDatabaseConnection")))
-	)
+	ctx, childSpan := tracing.TraceStart(ctx, r.tracer, "CheckDatabaseConnectionHandler", trace.WithAttributes(attribute.String("handler", "CheckDatabaseConnection")))
 
 	sqlDB, _ := r.db.DB()
-	if err := sqlDB.Ping(); err != nil {
+	if err := sqlDB.PingContext(ctx); err != nil {
 		utils.HandleErrors(ctx, fmt.Errorf("failed to connect to database server: connection refused"))
 		return fiber.ErrServiceUnavailable
 	}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type (
+	// Repositories is the data access of the service, the routes only depend on
+	// the interfaces so they can be served from in-memory fakes
+	Repositories struct {
+		Db   DbRepository
+		User UserRepository
+	}
+)
+
+// NewRepositories return the repositories backed by the database
+func NewRepositories(db *gorm.DB, tracer trace.Tracer) *Repositories {
+	return &Repositories{
+		Db:   NewDbRepository(db, tracer),
+		User: NewUserRepository(db, tracer),
+	}
+}
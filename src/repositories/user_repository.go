@@ -23,15 +23,17 @@ func NewUserRepository(db *gorm.DB, tracer trace.Tracer) UserRepository {
 }
 
 func (r userRepository) GetUserPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Pagination, error) {
+	ctx, childSpan := tracing.TraceStart(ctx, r.tracer, "GetUserPaginateRepository", trace.WithAttributes(attribute.String("repository", "GetUserPaginate"), attribute.String("search", search)))
+
 	var (
-		_, childSpan = tracing.TraceStart(ctx, r.tracer, "GetUserPaginateRepository", trace.WithAttributes(attribute.String("repository", "GetUserPaginate"), attribute.String("search", search)))
-		users        []models.User
-		err          error
+		users []models.User
+		err   error
 	)
 
 	// Pagination query
+	db := r.db.WithContext(ctx)
 	if search != "" {
-		if err = r.db.Scopes(database.Paginate(users, &pagination, r.db)).
+		if err = db.Scopes(database.Paginate(users, &pagination, db)).
 			Where(`email LIKE ?`, fmt.Sprintf(`%%%s%%`, search)).
 			Or(`first_name LIKE ?`, fmt.Sprintf(`%%%s%%`, search)).
 			Or(`last_name LIKE ?`, fmt.Sprintf(`%%%s%%`, search)).
@@ -40,7 +42,7 @@ func (r userRepository) GetUserPaginate(ctx context.Context, pagination database
 			return nil, utils.ErrQueryFailed
 		}
 	} else {
-		if err = r.db.Scopes(database.Paginate(users, &pagination, r.db)).
+		if err = db.Scopes(database.Paginate(users, &pagination, db)).
 			Find(&users).Error; err != nil {
 			utils.HandleErrors(ctx, err)
 			return nil, utils.ErrQueryFailed
@@ -56,14 +58,15 @@ func (r userRepository) GetUserPaginate(ctx context.Context, pagination database
 }
 
 func (r userRepository) GetUserByID(ctx context.Context, id int) (models.User, error) {
+	ctx, childSpan := tracing.TraceStart(ctx, r.tracer, "GetUserByIDRepository", trace.WithAttributes(attribute.String("repository", "GetUserByID"), attribute.Int("id", id)))
+
 	var (
-		_, childSpan = tracing.TraceStart(ctx, r.tracer, "GetUserByIDRepository", trace.WithAttributes(attribute.String("repository", "GetUserByID"), attribute.Int("id", id)))
-		user         models.User
-		err          error
+		user models.User
+		err  error
 	)
 
 	// Query
-	if err = r.db.First(&user, id).Error; err != nil {
+	if err = r.db.WithContext(ctx).First(&user, id).Error; err != nil {
 		utils.HandleErrors(ctx, err)
 		return user, err
 	}
@@ -74,13 +77,14 @@ func (r userRepository) GetUserByID(ctx context.Context, id int) (models.User, e
 }
 
 func (r userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, childSpan := tracing.TraceStart(ctx, r.tracer, "CreateUserRepository", trace.WithAttributes(attribute.String("repository", "CreateUser")))
+
 	var (
-		_, childSpan = tracing.TraceStart(ctx, r.tracer, "CreateUserRepository", trace.WithAttributes(attribute.String("repository", "CreateUser")))
-		err          error
+		err error
 	)
 
 	// Execute
-	if err = r.db.Create(&user).Error; err != nil {
+	if err = r.db.WithContext(ctx).Create(&user).Error; err != nil {
 		utils.HandleErrors(ctx, err)
 		return utils.ErrQueryFailed
 	}
@@ -91,14 +95,18 @@ func (r userRepository) CreateUser(ctx context.Context, user *models.User) error
 }
 
 func (r userRepository) UpdateUser(ctx context.Context, id int, user *models.User) error {
+	ctx, childSpan := tracing.TraceStart(ctx, r.tracer, "UpdateUserRepository", trace.WithAttributes(attribute.String("repository", "UpdateUser"), attribute.Int("id", id)))
+
 	var (
-		_, childSpan = tracing.TraceStart(ctx, r.tracer, "UpdateUserRepository", trace.WithAttributes(attribute.String("repository", "UpdateUser"), attribute.Int("id", id)))
-		existUser    *models.User
-		err          error
+		existUser *models.User
+		err       error
 	)
 
 	// Get model
-	r.db.First(&existUser)
+	if err = r.db.WithContext(ctx).First(&existUser, id).Error; err != nil {
+		utils.HandleErrors(ctx, err)
+		return err
+	}
 
 	// Set attributes
 	existUser.FirstName = user.FirstName
@@ -106,7 +114,7 @@ func (r userRepository) UpdateUser(ctx context.Context, id int, user *models.Use
 	existUser.Email = user.Email
 
 	// Execute
-	if err = r.db.Save(&existUser).Error; err != nil {
+	if err = r.db.WithContext(ctx).Save(&existUser).Error; err != nil {
 		utils.HandleErrors(ctx, err)
 		return utils.ErrQueryFailed
 	}
@@ -117,13 +125,14 @@ func (r userRepository) UpdateUser(ctx context.Context, id int, user *models.Use
 }
 
 func (r userRepository) DeleteUser(ctx context.Context, id int) error {
+	ctx, childSpan := tracing.TraceStart(ctx, r.tracer, "DeleteUserRepository", trace.WithAttributes(attribute.String("repository", "DeleteUser"), attribute.Int("id", id)))
+
 	var (
-		_, childSpan = tracing.TraceStart(ctx, r.tracer, "DeleteUserRepository", trace.WithAttributes(attribute.String("repository", "DeleteUser"), attribute.Int("id", id)))
-		err          error
+		err error
 	)
 
 	// Execute
-	if err = r.db.Delete(&models.User{}, id).Error; err != nil {
+	if err = r.db.WithContext(ctx).Delete(&models.User{}, id).Error; err != nil {
 		utils.HandleErrors(ctx, err)
 		return utils.ErrQueryFailed
 	}
@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"crypto/subtle"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/app/http_server"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/metrics"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/handlers"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/repositories"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/keyauth"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/monitor"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// Probe routes, reachable without the admin token and from any client IP, they
+// are called by the orchestrator
+var probePaths = map[string]bool{
+	"/health":   true,
+	"/livez":    true,
+	"/readyz":   true,
+	"/startupz": true,
+}
+
+func AdminMiddleware(s *http_server.HttpServer) {
+	admin := s.Admin()
+
+	admin.Use(middlewares.ClientIP(middlewares.NewClientIPResolver(s.Config())))
+	admin.Use(middlewares.RequestID())
+	admin.Use(logger.New(s.Config().Fiber.Middleware.Logger))
+	admin.Use(recover.New())
+	admin.Use(s.Reloadable(func(config *config.Config) fiber.Handler {
+		return middlewares.IPFilter(&middlewares.IPFilterConfig{
+			Next: func(c *fiber.Ctx) bool {
+				return probePaths[c.Path()]
+			},
+			Allow: config.Admin.AllowNetworks(),
+			Deny:  config.Admin.DenyNetworks(),
+		})
+	}))
+
+	// Optional bearer token
+	if token := s.Config().Admin.AdminToken; token != "" {
+		admin.Use(keyauth.New(keyauth.Config{
+			Next: func(c *fiber.Ctx) bool {
+				return probePaths[c.Path()]
+			},
+			Validator: func(c *fiber.Ctx, key string) (bool, error) {
+				if subtle.ConstantTimeCompare([]byte(key), []byte(token.Value())) != 1 {
+					return false, keyauth.ErrMissingOrMalformedAPIKey
+				}
+				return true, nil
+			},
+			ErrorHandler: func(c *fiber.Ctx, err error) error {
+				return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+			},
+		}))
+	}
+}
+
+// AdminRoutes register the routes of the internal admin server, it does
+// nothing when ADMIN_PORT is not set
+func AdminRoutes(s *http_server.HttpServer, repos *repositories.Repositories) {
+	admin := s.Admin()
+	if admin == nil {
+		return
+	}
+	AdminMiddleware(s)
+
+	// Initialize handlers
+	handler := handlers.NewHandler(s.Cacher, s.Tracer, repos.Db, nil)
+	adminHandler := handlers.NewAdminHandler(s.Config, s.Cacher)
+
+	if s.Config().Admin.AdminPprofEnabled {
+		admin.Use(pprof.New())
+	}
+
+	admin.Get("/health", healthCheck(s, handler))
+	admin.Get("/livez", s.Liveness().Handler())
+	admin.Get("/readyz", s.Readiness().Handler())
+	admin.Get("/startupz", s.Startup().Handler())
+	if s.Config().Fiber.MonitorEnabled {
+		admin.Get("/monitor", monitor.New(monitor.Config{Title: "Fiber Monitoring"}))
+	}
+	admin.Get("/metrics", metrics.HandlerFor(s.Gatherer()))
+	admin.Get("/config", func(c *fiber.Ctx) error { return adminHandler.GetConfig(c) })
+
+	// Cache admin
+	admin.Delete("/cache/tags/:tag", func(c *fiber.Ctx) error { return adminHandler.FlushCacheTag(c) })
+	admin.Delete("/cache/keys/:key", func(c *fiber.Ctx) error { return adminHandler.DeleteCacheKey(c) })
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/app/http_server"
+	userv1 "github.com/Stream-I-T-Consulting/stream-http-service-go/proto/user/v1"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/handlers"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/repositories"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/services"
+)
+
+// Access policies of the gRPC methods, the ones of the matching REST routes
+var grpcPolicies = map[string]*middlewares.Policy{
+	userv1.UserService_GetUsers_FullMethodName:   readUsers,
+	userv1.UserService_GetUser_FullMethodName:    readUsers,
+	userv1.UserService_CreateUser_FullMethodName: writeUsers,
+	userv1.UserService_UpdateUser_FullMethodName: writeUsers,
+	userv1.UserService_DeleteUser_FullMethodName: deleteUsers,
+}
+
+func GRPCRoutes(s *http_server.HttpServer, repos *repositories.Repositories) {
+	// Initialize services
+	userService := services.NewUserService(s.Tracer, repos.User)
+
+	// Bearer token authentication, each method then enforces its policy
+	if s.Config().OAuth.Enabled {
+		authConfig := middlewares.NewAuthConfig(s.Config())
+		authConfig.Users, authConfig.Cacher = repos.User, s.Cacher
+		s.OnRefresh(authConfig.RefreshKeys)
+		s.UseGRPC(
+			middlewares.AuthUnaryServerInterceptor(authConfig, grpcPolicies),
+			middlewares.AuthStreamServerInterceptor(authConfig, grpcPolicies),
+		)
+	}
+
+	// gRPC services ------------------------------------------------------------------
+	s.RegisterService(&userv1.UserService_ServiceDesc, handlers.NewUserGrpcHandler(s.Cacher, s.Tracer, userService))
+}
@@ -1,38 +1,93 @@
 package routes
 
 import (
+	"strings"
+	"sync/atomic"
+	"time"
+
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/app/http_server"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/health"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/openapi"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/handlers"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/repositories"
 	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func HTTPRootMiddleware(s *http_server.HttpServer) {
 	// Default middleware configs
-	fiberRequestID := requestid.New()
-	fiberETag := etag.New(s.Config.Fiber.Middleware.ETag)
-	fiberCors := cors.New(s.Config.Fiber.Middleware.Cors)
-	fiberLogger := logger.New(s.Config.Fiber.Middleware.Logger)
-	fiberFavicon := favicon.New(s.Config.Fiber.Middleware.Favicon)
-	fiberLimiter := limiter.New(s.Config.Fiber.Middleware.Limiter)
+	fiberETag := etag.New(s.Config().Fiber.Middleware.ETag)
+	fiberCors := s.Reloadable(func(config *config.Config) fiber.Handler {
+		return cors.New(config.Fiber.Middleware.Cors)
+	})
+	fiberLogger := logger.New(s.Config().Fiber.Middleware.Logger)
+	fiberFavicon := favicon.New(s.Config().Fiber.Middleware.Favicon)
+	// The storage falls back to memory on its own while Redis is down, a server
+	// without the cache component (the tests, the OpenAPI export) has none
+	limiterStorage := s.Storage("limiter")
+	if limiterStorage == nil {
+		if !fiber.IsChild() {
+			s.Log("HttpServer", "[WARN] No Redis storage, rate limiter counters are kept in memory by each process")
+		}
+		// Created once so that the counters survive config reloads
+		limiterStorage = cache.NewMemoryStorage()
+	}
+	// The rate limiter verifies the bearer tokens too, its public keys are
+	// refreshed with the ones of the routes
+	var limiterAuth atomic.Pointer[middlewares.AuthConfig]
+	s.OnRefresh(func() {
+		limiterAuth.Load().RefreshKeys()
+	})
+	rateLimit := s.Reloadable(func(config *config.Config) fiber.Handler {
+		rateLimitConfig := middlewares.NewRateLimitConfig(config, limiterStorage)
+		limiterAuth.Store(rateLimitConfig.Auth)
+		return middlewares.RateLimit(rateLimitConfig)
+	})
+	requestTimeout := s.Reloadable(func(config *config.Config) fiber.Handler {
+		return middlewares.Timeout(middlewares.NewTimeoutConfig(config))
+	})
+	ipFilter := s.Reloadable(func(config *config.Config) fiber.Handler {
+		return middlewares.IPFilter(&middlewares.IPFilterConfig{
+			Next: func(c *fiber.Ctx) bool {
+				return probePaths[c.Path()]
+			},
+			Allow:       config.Network.AllowNetworks(),
+			Deny:        config.Network.DenyNetworks(),
+			AllowGroups: config.Network.AllowGroups(),
+			DenyGroups:  config.Network.DenyGroups(),
+		})
+	})
 	fiberRecover := recover.New()
 
-	s.Use(fiberRequestID)
+	s.Use(middlewares.ClientIP(middlewares.NewClientIPResolver(s.Config())))
+	s.Use(middlewares.RequestID())
+	if s.Admin() != nil {
+		s.Use(middlewares.Metrics())
+	}
 	s.Use(fiberETag)
 	s.Use(fiberCors)
 	s.Use(fiberLogger)
 	s.Use(fiberFavicon)
-	s.Use(fiberLimiter)
+	s.Use(ipFilter)
+	s.Use(rateLimit)
+	s.Use(requestTimeout)
 	s.Use(fiberRecover)
+
+	// Expose the verified mTLS client certificate to the handlers
+	if s.Config().TLS.TLSClientCAFile != "" {
+		s.Use(middlewares.ClientCertificate())
+	}
 }
 
 func HTTPRootRoute(s *http_server.HttpServer) {
@@ -40,35 +95,196 @@ func HTTPRootRoute(s *http_server.HttpServer) {
 
 	s.GET("/", func(c *fiber.Ctx) error {
 		return handlers.GetRootPath(c)
-	})
-	s.GET("/monitor", monitor.New(monitor.Config{Title: "Fiber Monitoring"}))
+	}).Hidden()
+	// The admin server serves the monitor when it runs
+	if s.Config().Fiber.MonitorEnabled && s.Admin() == nil {
+		s.GET("/monitor", monitor.New(monitor.Config{Title: "Fiber Monitoring"})).Hidden()
+	}
 }
 
-func HTTPRoutes(s *http_server.HttpServer) {
+func HTTPRoutes(s *http_server.HttpServer, repos *repositories.Repositories) {
 	HTTPRootRoute(s)
 
-	// Initialize repositories, services, and handlers
-	dbRepo := repositories.NewDbRepository(s.DbClient, s.Tracer)
-	userRepo := repositories.NewUserRepository(s.DbClient, s.Tracer)
-
 	// Initialize services
-	userService := services.NewUserService(s.Tracer, userRepo)
+	userService := services.NewUserService(s.Tracer, repos.User)
 
 	// Initialize handlers
 	handler := handlers.NewHandler(
 		s.Cacher,
 		s.Tracer,
-		dbRepo,
+		repos.Db,
 		userService,
 	)
 
 	// REST API endpoint ------------------------------------------------------------------
-	s.GET("/health", func(c *fiber.Ctx) error { return handler.CheckDatabaseConnection(c) })
-
-	// User service routes
-	s.GET("/users", func(c *fiber.Ctx) error { return handler.GetUsers(c) })
-	s.GET("/users/:id", func(c *fiber.Ctx) error { return handler.GetUser(c) })
-	s.POST("/users", func(c *fiber.Ctx) error { return handler.CreateUser(c) })
-	s.PUT("/users/:id", func(c *fiber.Ctx) error { return handler.UpdateUser(c) })
-	s.DELETE("/users/:id", func(c *fiber.Ctx) error { return handler.DeleteUser(c) })
+	// The probes are only served on the admin server when it runs
+	if s.Admin() == nil {
+		s.GET("/health", healthCheck(s, handler)).
+			Summary("Database health check, kept for compatibility").
+			Tags("health").
+			Response(fiber.StatusOK, nil, "").
+			Response(fiber.StatusServiceUnavailable, nil, "")
+		s.GET("/livez", s.Liveness().Handler()).Summary("Liveness probe").Tags("health").
+			Response(fiber.StatusOK, health.Report{}, "")
+		s.GET("/readyz", s.Readiness().Handler()).Summary("Readiness probe").Tags("health").
+			Response(fiber.StatusOK, health.Report{}, "").
+			Response(fiber.StatusServiceUnavailable, health.Report{}, "")
+		s.GET("/startupz", s.Startup().Handler()).Summary("Startup probe").Tags("health").
+			Response(fiber.StatusOK, health.Report{}, "").
+			Response(fiber.StatusServiceUnavailable, health.Report{}, "")
+	}
+
+	// OpenAPI document of the routes registered through s, and its Swagger UI
+	if openAPI := s.Config().OpenAPI; openAPI.OpenAPIEnabled {
+		s.GET("/openapi.json", openapi.Handler(s.OpenAPI())).Hidden()
+		if openAPI.OpenAPISwaggerUIEnabled {
+			assets := openAPI.OpenAPISwaggerUIAssets
+			if openAPI.OpenAPISwaggerUIDir != "" {
+				assets = "/docs/assets"
+				s.App().Static(assets, openAPI.OpenAPISwaggerUIDir)
+			}
+			s.GET("/docs", openapi.SwaggerUI(s.Config().App.AppName, "/openapi.json", assets)).Hidden()
+		}
+	}
+
+	// Versioned API, an unversioned path with an API-Version header is routed
+	// to that version
+	s.Use(middlewares.APIVersion(&middlewares.APIVersionConfig{Versions: apiVersions}))
+
+	// Requests (and responses in the tests) checked against the OpenAPI
+	// document, the protected routes check them once authorized
+	validation := func(skipSecured bool) fiber.Handler {
+		return s.Reloadable(func(config *config.Config) fiber.Handler {
+			validationConfig := middlewares.NewValidationConfig(config, s.OpenAPI())
+			validationConfig.SkipSecured = skipSecured
+			return middlewares.Validation(validationConfig)
+		})
+	}
+	s.Use(validation(true))
+	routeValidation := validation(false)
+
+	// Bearer token authentication, each route then enforces its policy
+	var auth fiber.Handler
+	if s.Config().OAuth.Enabled {
+		authConfig := middlewares.NewAuthConfig(s.Config())
+		authConfig.Users, authConfig.Cacher = repos.User, s.Cacher
+		s.OnRefresh(authConfig.RefreshKeys)
+		auth = middlewares.AuthProtected(authConfig)
+		s.OpenAPI().SecurityScheme(bearerScheme, &openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
+	}
+
+	// API v1
+	userRoutes(s.Group("/v1"), handler, auth, routeValidation, &userVersion{getUser: handler.GetUser})
+
+	// API v2, GET /users/:id answers 404 for an unknown user
+	userRoutes(s.Group("/v2"), handler, auth, routeValidation, &userVersion{getUser: handler.GetUserV2, getUserNotFound: true})
+
+	// Unversioned routes, the v1 contract served before the API was versioned
+	userRoutes(s.Group(""), handler, auth, routeValidation, &userVersion{getUser: handler.GetUser, deprecation: unversionedDeprecation})
+}
+
+// bearerScheme is the OpenAPI security scheme of the bearer tokens
+const bearerScheme = "bearer"
+
+// Access policies of the user routes, enforced when OAUTH_ENABLED is set
+var (
+	readUsers   = &middlewares.Policy{Scopes: []string{"users:read"}}
+	writeUsers  = &middlewares.Policy{Scopes: []string{"users:write"}}
+	deleteUsers = &middlewares.Policy{Scopes: []string{"users:write"}, Roles: []string{"admin"}}
+)
+
+// apiVersions are the versions served under /<version>
+var apiVersions = []string{"v1", "v2"}
+
+// unversionedDeprecation retire the unversioned routes in favour of /v1
+var unversionedDeprecation = &middlewares.DeprecationConfig{
+	Since:     time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+	Successor: "/v1",
+}
+
+// userVersion is what differs between the versions of the user routes
+type userVersion struct {
+	// GET /users/:id handler
+	getUser fiber.Handler
+	// GET /users/:id answers 404 for an unknown user
+	getUserNotFound bool
+	// Deprecation of every route, nil when they are current
+	deprecation *middlewares.DeprecationConfig
+}
+
+// Body of the successful writes, documented in the OpenAPI document
+var okBody = openapi.Fields{"code": "", "message": ""}
+
+// userRoutes register and document the user service routes of an API version
+// on group, auth authenticates the requests before the route policies and the
+// validation when it is not nil
+func userRoutes(group *http_server.Group, handler handlers.Handler, auth fiber.Handler, validation fiber.Handler, version *userVersion) {
+	register := func(method func(string, ...fiber.Handler) *openapi.Route, path string, h fiber.Handler, policy *middlewares.Policy) *openapi.Route {
+		var chain []fiber.Handler
+		if version.deprecation != nil {
+			chain = append(chain, middlewares.Deprecated(version.deprecation))
+		}
+		if auth != nil {
+			chain = append(chain, auth, middlewares.Authorize(policy), validation)
+		}
+		r := method(path, append(chain, h)...)
+		if auth != nil {
+			r.Security(bearerScheme, policy.Scopes...).
+				Response(fiber.StatusUnauthorized, http_server.ErrorResponse{}, "Missing or invalid bearer token").
+				Response(fiber.StatusForbidden, http_server.ErrorResponse{}, "Denied by the route policy")
+			if len(policy.Roles) > 0 {
+				r.Description("Requires one of the roles: " + strings.Join(policy.Roles, ", ") + ".")
+			}
+		}
+		return r
+	}
+
+	routes := []*openapi.Route{
+		register(group.GET, "/users", handler.GetUsers, readUsers).
+			Summary("List users").
+			Query("page", openapi.IntegerRange(1, 0), "Page number, from 1").
+			Query("limit", openapi.IntegerRange(1, database.MaxLimit), "Users per page").
+			Query("search", "", "Filter on the email, first name or last name").
+			Response(fiber.StatusOK, openapi.Extend(database.Pagination{}, openapi.Fields{"data": []models.User{}}), ""),
+		register(group.GET, "/users/:id", version.getUser, readUsers).
+			Summary("Get a user").
+			PathParam("id", openapi.IntegerRange(1, 0), "User ID").
+			Response(fiber.StatusOK, openapi.Fields{"data": models.User{}}, ""),
+		register(group.POST, "/users", handler.CreateUser, writeUsers).
+			Summary("Create a user").
+			Body(services.UserDto{}).
+			Response(fiber.StatusCreated, okBody, "").
+			Response(fiber.StatusBadRequest, http_server.ErrorResponse{}, "Validation errors"),
+		register(group.PUT, "/users/:id", handler.UpdateUser, writeUsers).
+			Summary("Update a user").
+			PathParam("id", openapi.IntegerRange(1, 0), "User ID").
+			Body(services.UserDto{}).
+			Response(fiber.StatusOK, okBody, "").
+			Response(fiber.StatusBadRequest, http_server.ErrorResponse{}, "Validation errors"),
+		register(group.DELETE, "/users/:id", handler.DeleteUser, deleteUsers).
+			Summary("Delete a user").
+			PathParam("id", openapi.IntegerRange(1, 0), "User ID").
+			Response(fiber.StatusNoContent, nil, ""),
+	}
+	if version.getUserNotFound {
+		routes[1].Response(fiber.StatusNotFound, http_server.ErrorResponse{}, "Unknown user")
+	}
+	for _, r := range routes {
+		r.Tags("users")
+		if version.deprecation != nil {
+			r.Deprecated()
+		}
+	}
+}
+
+// healthCheck fail readiness while draining so load balancers stop routing
+// requests here
+func healthCheck(s *http_server.HttpServer, handler handlers.DbHandler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if s.Draining() {
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		}
+		return handler.CheckDatabaseConnection(c)
+	}
 }
@@ -15,8 +15,8 @@ type (
 		DeleteUser(ctx context.Context, id int) error
 	}
 	UserDto struct {
-		FirstName string `json:"first_name" form:"first_name" query:"first_name" validate:"required,max:50"`
-		LastName  string `json:"last_name" form:"last_name" query:"last_name" validate:"required,max:50"`
-		Email     string `json:"email" form:"email" query:"email" validate:"required,email,max:100"`
+		FirstName string `json:"first_name" form:"first_name" query:"first_name" validate:"required,max=50"`
+		LastName  string `json:"last_name" form:"last_name" query:"last_name" validate:"required,max=50"`
+		Email     string `json:"email" form:"email" query:"email" validate:"required,email,max=100"`
 	}
 )
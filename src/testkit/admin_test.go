@@ -0,0 +1,54 @@
+package testkit_test
+
+import (
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestOpsRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminPort  string
+		wantPublic int
+		wantAdmin  int
+	}{
+		{name: "without admin server", adminPort: "0", wantPublic: fiber.StatusOK},
+		{name: "with admin server", adminPort: "9000", wantPublic: fiber.StatusNotFound, wantAdmin: fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testkit.NewServer(t,
+				testkit.WithConfig("ADMIN_PORT", tt.adminPort),
+				testkit.WithConfig("MONITOR_ENABLED", "true"),
+			)
+
+			for _, path := range []string{"/health", "/livez", "/readyz", "/startupz", "/monitor"} {
+				if status := get(t, s.App(), path, ""); status != tt.wantPublic {
+					t.Errorf("public %s: status = %d, want %d", path, status, tt.wantPublic)
+				}
+				if s.Admin() == nil {
+					continue
+				}
+				if status := get(t, s.Admin(), path, ""); status != tt.wantAdmin {
+					t.Errorf("admin %s: status = %d, want %d", path, status, tt.wantAdmin)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminMonitorDisabled(t *testing.T) {
+	s := testkit.NewServer(t,
+		testkit.WithConfig("ADMIN_PORT", "9000"),
+		testkit.WithConfig("MONITOR_ENABLED", "false"),
+	)
+
+	if status := get(t, s.Admin(), "/monitor", ""); status != fiber.StatusNotFound {
+		t.Errorf("admin /monitor: status = %d, want %d", status, fiber.StatusNotFound)
+	}
+	if status := get(t, s.Admin(), "/metrics", ""); status != fiber.StatusOK {
+		t.Errorf("admin /metrics: status = %d, want %d", status, fiber.StatusOK)
+	}
+}
@@ -0,0 +1,78 @@
+package testkit_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPIVersion(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantServed string
+	}{
+		{name: "v1 path", path: "/v1/users/99", wantStatus: fiber.StatusInternalServerError, wantServed: "v1"},
+		{name: "v2 path", path: "/v2/users/99", wantStatus: fiber.StatusNotFound, wantServed: "v2"},
+		{name: "header", path: "/users/99", header: "2", wantStatus: fiber.StatusNotFound, wantServed: "v2"},
+		{name: "prefixed header", path: "/users/1", header: "v1", wantStatus: fiber.StatusOK, wantServed: "v1"},
+		{name: "path before header", path: "/v1/users/99", header: "2", wantStatus: fiber.StatusInternalServerError, wantServed: "v1"},
+		{name: "unsupported", path: "/users/1", header: "9", wantStatus: fiber.StatusBadRequest},
+		{name: "unversioned", path: "/users/1", wantStatus: fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("API-Version", tt.header)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if served := resp.Header.Get("API-Version"); served != tt.wantServed {
+				t.Errorf("API-Version = %q, want %q", served, tt.wantServed)
+			}
+		})
+	}
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/users/1", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+	}
+	if got := resp.Header.Get("Deprecation"); !strings.HasPrefix(got, "@") {
+		t.Errorf("Deprecation = %q, want a @<unix> date", got)
+	}
+	if got := resp.Header.Get("Sunset"); !strings.HasSuffix(got, " GMT") {
+		t.Errorf("Sunset = %q, want an HTTP date", got)
+	}
+	if got, want := resp.Header.Get("Link"), `</v1/users/1>; rel="successor-version"`; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/v1/users/1", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Deprecation"); got != "" {
+		t.Errorf("Deprecation = %q on /v1, want none", got)
+	}
+}
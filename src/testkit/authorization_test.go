@@ -0,0 +1,345 @@
+package testkit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// authorized send a request with the bearer token and return the status and
+// the error message
+func authorized(t *testing.T, app *fiber.App, method string, path string, token string, body string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var errorBody struct {
+		Message string `json:"message"`
+	}
+	if resp.StatusCode >= fiber.StatusBadRequest {
+		_ = json.NewDecoder(resp.Body).Decode(&errorBody)
+	}
+	return resp.StatusCode, errorBody.Message
+}
+
+func TestAuthorization(t *testing.T) {
+	issuer := testkit.NewIssuer(t)
+	app := testkit.NewApp(t, testkit.WithIssuer(issuer), testkit.WithUsers(users...))
+	other := testkit.NewIssuer(t)
+
+	// The subjects are the IDs of the users
+	reader := issuer.Token(t, jwt.MapClaims{"sub": "1", "scope": "users:read"})
+	writer := issuer.Token(t, jwt.MapClaims{"sub": "2", "scope": "users:read users:write"})
+	admin := issuer.Token(t, jwt.MapClaims{"sub": "3", "scope": "users:read users:write", "roles": []string{"admin"}})
+	body := `{"first_name":"Katherine","last_name":"Johnson","email":"katherine@example.com"}`
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		token       string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "anonymous", method: "GET", path: "/v1/users", wantStatus: fiber.StatusUnauthorized, wantMessage: "missing bearer token"},
+		{name: "anonymous invalid request", method: "POST", path: "/v1/users", body: `{"first_name":""}`, wantStatus: fiber.StatusUnauthorized, wantMessage: "missing bearer token"},
+		{name: "denied invalid request", method: "GET", path: "/v1/users/abc", token: issuer.Token(t, jwt.MapClaims{"sub": "1"}), wantStatus: fiber.StatusForbidden},
+		{name: "invalid request", method: "POST", path: "/v1/users", token: writer, body: `{"first_name":""}`, wantStatus: fiber.StatusBadRequest, wantMessage: "request does not match the OpenAPI document"},
+		{name: "unknown issuer", method: "GET", path: "/v1/users", token: other.Token(t, jwt.MapClaims{"sub": "1", "scope": "users:read"}), wantStatus: fiber.StatusUnauthorized},
+		{name: "unknown user", method: "GET", path: "/v1/users", token: issuer.Token(t, jwt.MapClaims{"sub": "42", "scope": "users:read"}), wantStatus: fiber.StatusUnauthorized, wantMessage: "unknown user"},
+		{name: "read", method: "GET", path: "/v1/users/1", token: reader, wantStatus: fiber.StatusOK},
+		{name: "missing scope", method: "POST", path: "/v1/users", token: reader, body: body, wantStatus: fiber.StatusForbidden, wantMessage: "forbidden: missing scope users:write"},
+		{name: "write", method: "POST", path: "/v1/users", token: writer, body: body, wantStatus: fiber.StatusCreated},
+		{name: "missing role", method: "DELETE", path: "/v2/users/2", token: writer, wantStatus: fiber.StatusForbidden, wantMessage: "forbidden: requires one of the roles admin"},
+		{name: "role", method: "DELETE", path: "/v2/users/2", token: admin, wantStatus: fiber.StatusNoContent},
+		{name: "deprecated routes", method: "GET", path: "/users", token: reader, wantStatus: fiber.StatusOK},
+		{name: "public routes", method: "GET", path: "/livez", wantStatus: fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := authorized(t, app, tt.method, tt.path, tt.token, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", status, message, tt.wantStatus)
+			}
+			if tt.wantMessage != "" && message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAuthorizationPolicy(t *testing.T) {
+	issuer := testkit.NewIssuer(t)
+	s := testkit.NewServer(t,
+		testkit.WithIssuer(issuer),
+		testkit.WithConfig("OAUTH_ROLES_CLAIM", "realm_access.roles"),
+	)
+	auth := middlewares.AuthProtected(middlewares.NewAuthConfig(s.Config()))
+	policy := &middlewares.Policy{
+		Roles:  []string{"support", "admin"},
+		Claims: map[string][]string{"tenant": {"acme"}},
+		Check: func(c *fiber.Ctx, principal *middlewares.Principal) error {
+			if verified, _ := principal.Claim("email_verified"); verified != true {
+				return errors.New("email not verified")
+			}
+			return nil
+		},
+	}
+	s.GET("/v1/whoami", auth, middlewares.Authorize(policy), func(c *fiber.Ctx) error {
+		principal := middlewares.GetPrincipal(c)
+		return c.JSON(fiber.Map{"subject": principal.Subject, "scopes": principal.Scopes, "roles": principal.Roles})
+	}).Hidden()
+	app := s.App()
+
+	claims := func(overrides jwt.MapClaims) string {
+		c := jwt.MapClaims{
+			"sub":            "ada",
+			"scope":          "users:read profile",
+			"realm_access":   map[string]interface{}{"roles": []string{"support"}},
+			"tenant":         "acme",
+			"email_verified": true,
+		}
+		for name, value := range overrides {
+			c[name] = value
+		}
+		return issuer.Token(t, c)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "allowed", token: claims(nil), wantStatus: fiber.StatusOK},
+		{name: "nested roles", token: claims(jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []string{"guest"}}}), wantStatus: fiber.StatusForbidden, wantMessage: "forbidden: requires one of the roles support, admin"},
+		{name: "claim", token: claims(jwt.MapClaims{"tenant": "globex"}), wantStatus: fiber.StatusForbidden, wantMessage: "forbidden: claim tenant must be one of acme"},
+		{name: "check", token: claims(jwt.MapClaims{"email_verified": false}), wantStatus: fiber.StatusForbidden, wantMessage: "forbidden: email not verified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := authorized(t, app, "GET", "/v1/whoami", tt.token, "")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", status, message, tt.wantStatus)
+			}
+			if message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", message, tt.wantMessage)
+			}
+		})
+	}
+
+	// The handler reads the typed principal
+	req := httptest.NewRequest("GET", "/v1/whoami", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+claims(nil))
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var principal struct {
+		Subject string   `json:"subject"`
+		Scopes  []string `json:"scopes"`
+		Roles   []string `json:"roles"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&principal); err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != "ada" || strings.Join(principal.Scopes, " ") != "users:read profile" || strings.Join(principal.Roles, " ") != "support" {
+		t.Errorf("principal = %+v", principal)
+	}
+}
+
+func TestAuthorizationDocument(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithIssuer(testkit.NewIssuer(t)))
+
+	var document struct {
+		Paths map[string]map[string]struct {
+			Security  []map[string][]string  `json:"security"`
+			Responses map[string]interface{} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]struct {
+				Type   string `json:"type"`
+				Scheme string `json:"scheme"`
+			} `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	if status := do(t, app, "GET", "/openapi.json", nil, &document); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+
+	if scheme := document.Components.SecuritySchemes["bearer"]; scheme.Type != "http" || scheme.Scheme != "bearer" {
+		t.Errorf("bearer scheme = %+v", scheme)
+	}
+	operation := document.Paths["/v1/users/{id}"]["delete"]
+	if len(operation.Security) != 1 || strings.Join(operation.Security[0]["bearer"], " ") != "users:write" {
+		t.Errorf("security = %v, want bearer users:write", operation.Security)
+	}
+	for _, status := range []string{"401", "403"} {
+		if _, ok := operation.Responses[status]; !ok {
+			t.Errorf("response %s is not documented", status)
+		}
+	}
+	if security := document.Paths["/livez"]["get"].Security; len(security) != 0 {
+		t.Errorf("/livez security = %v, want none", security)
+	}
+}
+
+func TestAuthenticatedUser(t *testing.T) {
+	issuer := testkit.NewIssuer(t)
+	s := testkit.NewServer(t, testkit.WithIssuer(issuer), testkit.WithUsers(users...))
+	authConfig := middlewares.NewAuthConfig(s.Config())
+	authConfig.Users, authConfig.Cacher = s.Users, s.Cache
+	s.GET("/v1/me", middlewares.AuthProtected(authConfig), func(c *fiber.Ctx) error {
+		user := middlewares.GetUser(c)
+		if models.UserFromContext(c.UserContext()) != user {
+			return errors.New("the context user is not the request user")
+		}
+		return c.JSON(user)
+	}).Hidden()
+	app := s.App()
+	token := issuer.Token(t, jwt.MapClaims{"sub": "1", "scope": "users:read users:write", "roles": "admin"})
+
+	req := httptest.NewRequest("GET", "/v1/me", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusOK)
+	}
+	var user models.User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 1 || user.Email != "ada@example.com" {
+		t.Errorf("user = %+v, want ada", user)
+	}
+	if keys := s.Cache.Keys(); !slices.Contains(keys, "auth:user:1") {
+		t.Errorf("cache keys = %v, want auth:user:1", keys)
+	}
+
+	// Served from the cache while the database is down
+	s.Users.Fail(errors.New("connection refused"))
+	if status, message := authorized(t, app, "GET", "/v1/me", token, ""); status != fiber.StatusOK {
+		t.Errorf("status = %d (%s), want %d from the cache", status, message, fiber.StatusOK)
+	}
+	s.Users.Fail(nil)
+
+	// Deleting the user flushes the cached users, its tokens are rejected
+	if status, message := authorized(t, app, "DELETE", "/v1/users/1", token, ""); status != fiber.StatusNoContent {
+		t.Fatalf("status = %d (%s), want %d", status, message, fiber.StatusNoContent)
+	}
+	if status, message := authorized(t, app, "GET", "/v1/me", token, ""); status != fiber.StatusUnauthorized || message != "unknown user" {
+		t.Errorf("status = %d (%s), want %d unknown user", status, message, fiber.StatusUnauthorized)
+	}
+}
+
+func TestAuthenticatedUserNotCached(t *testing.T) {
+	issuer := testkit.NewIssuer(t)
+	s := testkit.NewServer(t,
+		testkit.WithIssuer(issuer),
+		testkit.WithUsers(users...),
+		testkit.WithConfig("OAUTH_USER_CACHE_TTL", "0s"),
+	)
+	app := s.App()
+	token := issuer.Token(t, jwt.MapClaims{"sub": "2", "scope": "users:read"})
+
+	if status, message := authorized(t, app, "GET", "/v1/users/2", token, ""); status != fiber.StatusOK {
+		t.Fatalf("status = %d (%s), want %d", status, message, fiber.StatusOK)
+	}
+	if keys := s.Cache.Keys(); slices.Contains(keys, "auth:user:2") {
+		t.Errorf("cache keys = %v, want no cached user", keys)
+	}
+
+	// Every request loads the user
+	s.Users.Fail(errors.New("connection refused"))
+	if status, message := authorized(t, app, "GET", "/v1/users/2", token, ""); status != fiber.StatusInternalServerError || message != "user lookup failed" {
+		t.Errorf("status = %d (%s), want %d user lookup failed", status, message, fiber.StatusInternalServerError)
+	}
+}
+
+func TestPublicKeyRotation(t *testing.T) {
+	current, next := testkit.NewIssuer(t), testkit.NewIssuer(t)
+	path := filepath.Join(t.TempDir(), "oauth.pem")
+	rotate := func(issuer *testkit.Issuer) {
+		if err := os.WriteFile(path, []byte(issuer.PublicKey()), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newServer := func(keysTTL string) *testkit.Server {
+		rotate(current)
+		return testkit.NewServer(t,
+			testkit.WithUsers(users...),
+			testkit.WithConfig("OAUTH_ENABLED", "true"),
+			testkit.WithConfig("OAUTH_ALGORITHMS", "EdDSA"),
+			testkit.WithConfig("OAUTH_PUBLIC_KEY_PATHS", path),
+			testkit.WithConfig("OAUTH_KEYS_TTL", keysTTL),
+		)
+	}
+	check := func(t *testing.T, app *fiber.App, issuer *testkit.Issuer, want int) {
+		t.Helper()
+		token := issuer.Token(t, jwt.MapClaims{"sub": "1", "scope": "users:read"})
+		if status, message := authorized(t, app, "GET", "/v1/users/1", token, ""); status != want {
+			t.Errorf("status = %d (%s), want %d", status, message, want)
+		}
+	}
+
+	t.Run("reload", func(t *testing.T) {
+		s := newServer("5m")
+		app := s.App()
+		check(t, app, current, fiber.StatusOK)
+
+		// The keys are cached for OAUTH_KEYS_TTL, a reload reads them again
+		rotate(next)
+		check(t, app, next, fiber.StatusUnauthorized)
+		// The reload reads the command line, not the flags of the test binary
+		args := os.Args
+		os.Args = args[:1]
+		t.Cleanup(func() { os.Args = args })
+		s.Reload()
+		check(t, app, next, fiber.StatusOK)
+		check(t, app, current, fiber.StatusUnauthorized)
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		app := newServer("0s").App()
+		check(t, app, current, fiber.StatusOK)
+		rotate(next)
+		check(t, app, next, fiber.StatusOK)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		app := newServer("0s").App()
+		check(t, app, current, fiber.StatusOK)
+
+		// Halfway through a rotation the current keys are kept
+		if err := os.WriteFile(path, []byte("-----BEGIN PUBLIC"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		check(t, app, current, fiber.StatusOK)
+	})
+}
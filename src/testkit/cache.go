@@ -0,0 +1,124 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/cache"
+)
+
+type (
+	// Cache is an in-memory cache.Cacher, values are stored as JSON like in
+	// Redis so that what cannot be cached fails the same way
+	Cache struct {
+		store *cacheStore
+		tags  []string
+	}
+	cacheStore struct {
+		mu      sync.Mutex
+		values  map[string]cacheValue
+		tags    map[string]map[string]bool
+		expired time.Duration
+	}
+	cacheValue struct {
+		data    []byte
+		expires time.Time
+	}
+)
+
+// NewCache return an empty in-memory cache whose values never expire
+func NewCache() *Cache {
+	return &Cache{
+		store: &cacheStore{
+			values: map[string]cacheValue{},
+			tags:   map[string]map[string]bool{},
+		},
+	}
+}
+
+func (c *Cache) Get(ctx context.Context, key string, val interface{}) error {
+	c.store.mu.Lock()
+	value, ok := c.store.values[key]
+	if ok && !value.expires.IsZero() && time.Now().After(value.expires) {
+		delete(c.store.values, key)
+		ok = false
+	}
+	c.store.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(value.data, &val)
+}
+
+func (c *Cache) Set(ctx context.Context, key string, val interface{}) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	value := cacheValue{data: data}
+	if c.store.expired > 0 {
+		value.expires = time.Now().Add(c.store.expired)
+	}
+	c.store.values[key] = value
+	for _, tag := range c.tags {
+		if c.store.tags[tag] == nil {
+			c.store.tags[tag] = map[string]bool{}
+		}
+		c.store.tags[tag][key] = true
+	}
+	return nil
+}
+
+func (c *Cache) Tag(tag ...string) cache.Cacher {
+	return &Cache{store: c.store, tags: tag}
+}
+
+func (c *Cache) Flush(ctx context.Context) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	for _, tag := range c.tags {
+		for key := range c.store.tags[tag] {
+			delete(c.store.values, key)
+		}
+		delete(c.store.tags, tag)
+	}
+	return nil
+}
+
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.store.values, key)
+	}
+	return nil
+}
+
+func (c *Cache) SetExpired(exp time.Duration) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	c.store.expired = exp
+}
+
+// Keys return the cached keys, sorted
+func (c *Cache) Keys() []string {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	keys := make([]string, 0, len(c.store.values))
+	for key := range c.store.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+package testkit_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// app.Test() requests come from 0.0.0.0
+func get(t *testing.T, app *fiber.App, path string, forwardedFor string) int {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", path, nil)
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestClientIPTrustedProxy(t *testing.T) {
+	app := testkit.NewApp(t,
+		testkit.WithConfig("TRUSTED_PROXIES", "0.0.0.0,10.0.0.0/8"),
+		testkit.WithConfig("RATE_LIMIT", "1"),
+	)
+
+	// Limited per client, the forged first hop and the trusted proxy are skipped
+	if status := get(t, app, "/", "6.6.6.6, 192.0.2.1, 10.0.0.2"); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+	if status := get(t, app, "/", "7.7.7.7, 192.0.2.1"); status != fiber.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d for the same client", status, fiber.StatusTooManyRequests)
+	}
+	if status := get(t, app, "/", "192.0.2.2"); status != fiber.StatusOK {
+		t.Errorf("status = %d, want %d for another client", status, fiber.StatusOK)
+	}
+}
+
+func TestClientIPUntrustedProxy(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithConfig("RATE_LIMIT", "1"))
+
+	// The header is ignored, every request comes from the connection address
+	get(t, app, "/", "192.0.2.1")
+	if status := get(t, app, "/", "192.0.2.2"); status != fiber.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", status, fiber.StatusTooManyRequests)
+	}
+}
+
+func TestIPFilter(t *testing.T) {
+	app := testkit.NewApp(t,
+		testkit.WithConfig("TRUSTED_PROXIES", "0.0.0.0"),
+		testkit.WithConfig("IP_ALLOW", "192.0.2.0/24"),
+		testkit.WithConfig("IP_DENY", "192.0.2.66"),
+	)
+
+	tests := []struct {
+		client string
+		path   string
+		want   int
+	}{
+		{client: "192.0.2.1", path: "/", want: fiber.StatusOK},
+		{client: "192.0.2.66", path: "/", want: fiber.StatusForbidden},
+		{client: "198.51.100.1", path: "/", want: fiber.StatusForbidden},
+		{client: "198.51.100.1", path: "/livez", want: fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		if status := get(t, app, tt.path, tt.client); status != tt.want {
+			t.Errorf("%s from %s: status = %d, want %d", tt.path, tt.client, status, tt.want)
+		}
+	}
+}
+
+func TestIPFilterGroups(t *testing.T) {
+	app := testkit.NewApp(t,
+		testkit.WithConfig("TRUSTED_PROXIES", "0.0.0.0"),
+		testkit.WithConfig("IP_DENY", "203.0.113.9"),
+		testkit.WithConfig("IP_ALLOW_GROUPS", "/v2/*=192.0.2.0/24,/v2/users/:id=192.0.2.0/25"),
+		testkit.WithConfig("IP_DENY_GROUPS", "/v1/*=198.51.100.0/24"),
+		testkit.WithUsers(users...),
+	)
+
+	tests := []struct {
+		client  string
+		path    string
+		version string
+		want    int
+	}{
+		{client: "198.51.100.1", path: "/", want: fiber.StatusOK},
+		{client: "198.51.100.1", path: "/v1/users", want: fiber.StatusForbidden},
+		{client: "192.0.2.1", path: "/v1/users", want: fiber.StatusOK},
+		{client: "192.0.2.1", path: "/v2/users", want: fiber.StatusOK},
+		{client: "198.51.100.1", path: "/v2/users", want: fiber.StatusForbidden},
+		// Every matching group applies
+		{client: "192.0.2.1", path: "/v2/users/1", want: fiber.StatusOK},
+		{client: "192.0.2.200", path: "/v2/users/1", want: fiber.StatusForbidden},
+		// The groups of a version apply to its negotiated requests
+		{client: "198.51.100.1", path: "/users", version: "2", want: fiber.StatusForbidden},
+		{client: "198.51.100.1", path: "/users", version: "1", want: fiber.StatusForbidden},
+		{client: "192.0.2.1", path: "/users", version: "2", want: fiber.StatusOK},
+		// The server lists still apply
+		{client: "203.0.113.9", path: "/", want: fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("X-Forwarded-For", tt.client)
+		if tt.version != "" {
+			req.Header.Set("API-Version", tt.version)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s (API-Version %q) from %s: status = %d, want %d", tt.path, tt.version, tt.client, resp.StatusCode, tt.want)
+		}
+	}
+}
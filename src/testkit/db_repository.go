@@ -0,0 +1,30 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+)
+
+// DbRepository is an in-memory repositories.DbRepository, the database is up
+// until Fail is called
+type DbRepository struct {
+	mu  sync.Mutex
+	err error
+}
+
+func NewDbRepository() *DbRepository {
+	return &DbRepository{}
+}
+
+func (r *DbRepository) CheckDatabaseConnection(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Fail make the connection check return err, or succeed again with nil
+func (r *DbRepository) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
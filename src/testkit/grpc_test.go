@@ -0,0 +1,168 @@
+package testkit_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	userv1 "github.com/Stream-I-T-Consulting/stream-http-service-go/proto/user/v1"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// bearer return the context of a gRPC call with the bearer token
+func bearer(token string) context.Context {
+	if token == "" {
+		return context.Background()
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGRPCAuthorization(t *testing.T) {
+	issuer := testkit.NewIssuer(t)
+	s := testkit.NewServer(t,
+		testkit.WithIssuer(issuer),
+		testkit.WithConfig("GRPC_ENABLED", "true"),
+		testkit.WithUsers(users...),
+	)
+	conn := s.DialGRPC(t)
+	client := userv1.NewUserServiceClient(conn)
+	other := testkit.NewIssuer(t)
+
+	// The subjects are the IDs of the users
+	reader := issuer.Token(t, jwt.MapClaims{"sub": "1", "scope": "users:read"})
+	writer := issuer.Token(t, jwt.MapClaims{"sub": "2", "scope": "users:read users:write"})
+	admin := issuer.Token(t, jwt.MapClaims{"sub": "3", "scope": "users:read users:write", "roles": []string{"admin"}})
+	create := &userv1.CreateUserRequest{FirstName: "Katherine", LastName: "Johnson", Email: "katherine@example.com"}
+
+	tests := []struct {
+		name        string
+		token       string
+		call        func(ctx context.Context) error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{name: "anonymous", call: func(ctx context.Context) error {
+			_, err := client.GetUsers(ctx, &userv1.GetUsersRequest{})
+			return err
+		}, wantCode: codes.Unauthenticated, wantMessage: "missing bearer token"},
+		{name: "unknown issuer", token: other.Token(t, jwt.MapClaims{"sub": "1", "scope": "users:read"}), call: func(ctx context.Context) error {
+			_, err := client.GetUsers(ctx, &userv1.GetUsersRequest{})
+			return err
+		}, wantCode: codes.Unauthenticated},
+		{name: "unknown user", token: issuer.Token(t, jwt.MapClaims{"sub": "42", "scope": "users:read"}), call: func(ctx context.Context) error {
+			_, err := client.GetUsers(ctx, &userv1.GetUsersRequest{})
+			return err
+		}, wantCode: codes.Unauthenticated, wantMessage: "unknown user"},
+		{name: "read", token: reader, call: func(ctx context.Context) error {
+			_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: 1})
+			return err
+		}, wantCode: codes.OK},
+		{name: "missing scope", token: reader, call: func(ctx context.Context) error {
+			_, err := client.CreateUser(ctx, create)
+			return err
+		}, wantCode: codes.PermissionDenied, wantMessage: "forbidden: missing scope users:write"},
+		{name: "write", token: writer, call: func(ctx context.Context) error {
+			_, err := client.CreateUser(ctx, create)
+			return err
+		}, wantCode: codes.OK},
+		{name: "missing role", token: writer, call: func(ctx context.Context) error {
+			_, err := client.DeleteUser(ctx, &userv1.DeleteUserRequest{Id: 2})
+			return err
+		}, wantCode: codes.PermissionDenied, wantMessage: "forbidden: requires one of the roles admin"},
+		{name: "role", token: admin, call: func(ctx context.Context) error {
+			_, err := client.DeleteUser(ctx, &userv1.DeleteUserRequest{Id: 2})
+			return err
+		}, wantCode: codes.OK},
+		{name: "public health service", call: func(ctx context.Context) error {
+			_, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+			return err
+		}, wantCode: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(tt.call(bearer(tt.token)))
+			if st.Code() != tt.wantCode {
+				t.Fatalf("code = %s (%s), want %s", st.Code(), st.Message(), tt.wantCode)
+			}
+			if tt.wantMessage != "" && st.Message() != tt.wantMessage {
+				t.Errorf("message = %q, want %q", st.Message(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestGRPCRequestValidation(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithConfig("GRPC_ENABLED", "true"), testkit.WithUsers(users...))
+	client := userv1.NewUserServiceClient(s.DialGRPC(t))
+	update := &userv1.UpdateUserRequest{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"}
+
+	tests := []struct {
+		name        string
+		call        func(ctx context.Context) error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{name: "page below 1", call: func(ctx context.Context) error {
+			_, err := client.GetUsers(ctx, &userv1.GetUsersRequest{Page: -1})
+			return err
+		}, wantCode: codes.InvalidArgument, wantMessage: "page must be 1 or more"},
+		{name: "limit below 1", call: func(ctx context.Context) error {
+			_, err := client.GetUsers(ctx, &userv1.GetUsersRequest{Limit: -1})
+			return err
+		}, wantCode: codes.InvalidArgument, wantMessage: "limit must be between 1 and 100"},
+		{name: "limit above the maximum", call: func(ctx context.Context) error {
+			_, err := client.GetUsers(ctx, &userv1.GetUsersRequest{Limit: 1000})
+			return err
+		}, wantCode: codes.InvalidArgument, wantMessage: "limit must be between 1 and 100"},
+		{name: "default pagination", call: func(ctx context.Context) error {
+			_, err := client.GetUsers(ctx, &userv1.GetUsersRequest{})
+			return err
+		}, wantCode: codes.OK},
+		{name: "get id below 1", call: func(ctx context.Context) error {
+			_, err := client.GetUser(ctx, &userv1.GetUserRequest{})
+			return err
+		}, wantCode: codes.InvalidArgument, wantMessage: "id must be a positive integer"},
+		{name: "update id below 1", call: func(ctx context.Context) error {
+			_, err := client.UpdateUser(ctx, update)
+			return err
+		}, wantCode: codes.InvalidArgument, wantMessage: "id must be a positive integer"},
+		{name: "delete id out of range", call: func(ctx context.Context) error {
+			_, err := client.DeleteUser(ctx, &userv1.DeleteUserRequest{Id: math.MaxUint64})
+			return err
+		}, wantCode: codes.InvalidArgument, wantMessage: "id must be a positive integer"},
+		{name: "unknown user", call: func(ctx context.Context) error {
+			_, err := client.GetUser(ctx, &userv1.GetUserRequest{Id: 42})
+			return err
+		}, wantCode: codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(tt.call(context.Background()))
+			if st.Code() != tt.wantCode {
+				t.Fatalf("code = %s (%s), want %s", st.Code(), st.Message(), tt.wantCode)
+			}
+			if tt.wantMessage != "" && st.Message() != tt.wantMessage {
+				t.Errorf("message = %q, want %q", st.Message(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestGRPCInternalError(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithConfig("GRPC_ENABLED", "true"), testkit.WithUsers(users...))
+	client := userv1.NewUserServiceClient(s.DialGRPC(t))
+
+	// The cause is logged, not sent to the client
+	s.Users.Fail(errors.New("pq: connection refused to 10.0.0.5"))
+	_, err := client.GetUser(context.Background(), &userv1.GetUserRequest{Id: 1})
+	st := status.Convert(err)
+	if st.Code() != codes.Internal || st.Message() != "internal error" {
+		t.Errorf("status = %s %q, want %s %q", st.Code(), st.Message(), codes.Internal, "internal error")
+	}
+}
@@ -0,0 +1,69 @@
+package testkit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Issuer sign bearer tokens for a test server, WithIssuer enables the
+// authentication with its public key
+type Issuer struct {
+	key       ed25519.PrivateKey
+	publicKey string
+}
+
+// NewIssuer return an issuer with a new EdDSA key
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+
+	return &Issuer{
+		key:       private,
+		publicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}
+}
+
+// WithIssuer require the bearer tokens of the issuer on the routes
+func WithIssuer(issuer *Issuer) Option {
+	return func(o *options) {
+		o.values["OAUTH_ENABLED"] = "true"
+		o.values["OAUTH_PUBLIC_KEY"] = issuer.publicKey
+		o.values["OAUTH_ALGORITHMS"] = "EdDSA"
+	}
+}
+
+// PublicKey return the PEM public key of the issuer, e.g. to write it to an
+// OAUTH_PUBLIC_KEY_PATHS file
+func (i *Issuer) PublicKey() string {
+	return i.publicKey
+}
+
+// Token sign the claims, the token expires in an hour unless the claims set
+// "exp"
+func (i *Issuer) Token(t testing.TB, claims jwt.MapClaims) string {
+	t.Helper()
+
+	signed := jwt.MapClaims{"iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix()}
+	for name, value := range claims {
+		signed[name] = value
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, signed).SignedString(i.key)
+	if err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+	return token
+}
@@ -0,0 +1,126 @@
+package testkit_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	openAPIDocument struct {
+		OpenAPI    string                                 `json:"openapi"`
+		Paths      map[string]map[string]openAPIOperation `json:"paths"`
+		Components struct {
+			Schemas map[string]openAPISchema `json:"schemas"`
+		} `json:"components"`
+	}
+	openAPIOperation struct {
+		Deprecated  bool                       `json:"deprecated"`
+		RequestBody *struct{}                  `json:"requestBody"`
+		Responses   map[string]json.RawMessage `json:"responses"`
+	}
+	openAPISchema struct {
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Format    string `json:"format"`
+			MaxLength int    `json:"maxLength"`
+		} `json:"properties"`
+	}
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	app := testkit.NewApp(t)
+
+	var document openAPIDocument
+	if status := do(t, app, "GET", "/openapi.json", nil, &document); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+	if document.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q, want 3.1.0", document.OpenAPI)
+	}
+
+	for _, path := range []string{"/v1/users", "/v1/users/{id}", "/v2/users", "/v2/users/{id}", "/users", "/users/{id}", "/readyz"} {
+		if _, ok := document.Paths[path]; !ok {
+			t.Errorf("path %s is not documented", path)
+		}
+	}
+	for _, path := range []string{"/", "/openapi.json", "/docs"} {
+		if _, ok := document.Paths[path]; ok {
+			t.Errorf("path %s is documented, want it hidden", path)
+		}
+	}
+
+	if !document.Paths["/users"]["get"].Deprecated || document.Paths["/v1/users"]["get"].Deprecated {
+		t.Error("want the unversioned routes deprecated and the v1 routes current")
+	}
+	if _, ok := document.Paths["/v2/users/{id}"]["get"].Responses["404"]; !ok {
+		t.Error("GET /v2/users/{id} does not document its 404")
+	}
+	if document.Paths["/v1/users"]["post"].RequestBody == nil {
+		t.Error("POST /v1/users does not document its body")
+	}
+
+	dto := document.Components.Schemas["UserDto"]
+	if strings.Join(dto.Required, ",") != "first_name,last_name,email" {
+		t.Errorf("UserDto required = %v, want the validate required fields", dto.Required)
+	}
+	if email := dto.Properties["email"]; email.Format != "email" || email.MaxLength != 100 {
+		t.Errorf("UserDto email = %+v, want the email format and max length 100", email)
+	}
+	if _, ok := document.Components.Schemas["User"].Properties["created_at"]; !ok {
+		t.Error("User does not document the fields of the embedded Model")
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	app := testkit.NewApp(t)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/docs", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || !strings.Contains(string(body), `"/openapi.json"`) {
+		t.Errorf("status = %d, want the Swagger UI page of /openapi.json", resp.StatusCode)
+	}
+
+	app = testkit.NewApp(t, testkit.WithConfig("OPENAPI_SWAGGER_UI_ENABLED", "false"))
+	if status := do(t, app, "GET", "/docs", nil, nil); status != fiber.StatusNotFound {
+		t.Errorf("status = %d with OPENAPI_SWAGGER_UI_ENABLED=false, want %d", status, fiber.StatusNotFound)
+	}
+	if status := do(t, app, "GET", "/openapi.json", nil, nil); status != fiber.StatusOK {
+		t.Errorf("status = %d with OPENAPI_SWAGGER_UI_ENABLED=false, want the document", status)
+	}
+
+	app = testkit.NewApp(t, testkit.WithConfig("OPENAPI_ENABLED", "false"))
+	if status := do(t, app, "GET", "/openapi.json", nil, nil); status != fiber.StatusNotFound {
+		t.Errorf("status = %d with OPENAPI_ENABLED=false, want %d", status, fiber.StatusNotFound)
+	}
+}
+
+func TestSwaggerUILocalAssets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "swagger-ui-bundle.js"), []byte("// bundle"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app := testkit.NewApp(t, testkit.WithConfig("OPENAPI_SWAGGER_UI_DIR", dir))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/docs", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `src="/docs/assets/swagger-ui-bundle.js"`) || strings.Contains(string(body), "unpkg.com") {
+		t.Errorf("page = %s, want the local assets", body)
+	}
+	if status := do(t, app, "GET", "/docs/assets/swagger-ui-bundle.js", nil, nil); status != fiber.StatusOK {
+		t.Errorf("asset status = %d, want %d", status, fiber.StatusOK)
+	}
+}
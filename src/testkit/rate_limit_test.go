@@ -0,0 +1,173 @@
+package testkit_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/middlewares"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRateLimitTiers(t *testing.T) {
+	issuer := testkit.NewIssuer(t)
+	app := testkit.NewApp(t,
+		testkit.WithIssuer(issuer),
+		testkit.WithUsers(users...),
+		testkit.WithConfig("RATE_LIMIT", "2"),
+		testkit.WithConfig("RATE_LIMIT_TIERS", "standard:5,premium:50"),
+		testkit.WithConfig("RATE_LIMIT_TIER_CLAIM", "plan.tier"),
+	)
+
+	tests := []struct {
+		name      string
+		token     string
+		wantLimit string
+	}{
+		{name: "anonymous", wantLimit: "2"},
+		{name: "nested tier claim", token: issuer.Token(t, jwt.MapClaims{"sub": "1", "scope": "users:read", "plan": map[string]interface{}{"tier": "premium"}}), wantLimit: "50"},
+		{name: "default tier", token: issuer.Token(t, jwt.MapClaims{"sub": "2", "scope": "users:read"}), wantLimit: "5"},
+		{name: "unknown tier", token: issuer.Token(t, jwt.MapClaims{"sub": "3", "scope": "users:read", "plan": map[string]interface{}{"tier": "gold"}}), wantLimit: "5"},
+		{name: "invalid token counted per IP", token: testkit.NewIssuer(t).Token(t, jwt.MapClaims{"sub": "1", "plan": map[string]interface{}{"tier": "premium"}}), wantLimit: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/users", nil)
+			if tt.token != "" {
+				req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Header.Get("RateLimit-Limit"); got != tt.wantLimit {
+				t.Errorf("RateLimit-Limit = %q, want %q", got, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestRateLimitVersionedRouteCost(t *testing.T) {
+	app := testkit.NewApp(t,
+		testkit.WithUsers(users...),
+		testkit.WithConfig("RATE_LIMIT", "100"),
+		testkit.WithConfig("RATE_LIMIT_COSTS", "GET /users/:id:5,GET /v2/users?search:20"),
+	)
+
+	tests := []struct {
+		name          string
+		path          string
+		version       string
+		wantRemaining int
+	}{
+		{name: "unversioned route, versioned path", path: "/v1/users/1", wantRemaining: 95},
+		{name: "unversioned route, negotiated version", path: "/users/1", version: "2", wantRemaining: 90},
+		{name: "versioned route", path: "/v2/users?search=ada", wantRemaining: 70},
+		{name: "versioned route, other version", path: "/v1/users?search=ada", wantRemaining: 69},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.version != "" {
+				req.Header.Set("API-Version", tt.version)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Header.Get("RateLimit-Remaining"); got != strconv.Itoa(tt.wantRemaining) {
+				t.Errorf("RateLimit-Remaining = %s, want %d", got, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestRateLimitBypass(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	sign := func(method string, path string, expires time.Duration) string {
+		return middlewares.SignRateLimitBypass(secret, method, path, time.Now().Add(expires))
+	}
+
+	tests := []struct {
+		name   string
+		client string
+		header string
+		want   int
+	}{
+		{name: "without header", want: fiber.StatusTooManyRequests},
+		{name: "signed", header: sign("GET", "/", time.Hour), want: fiber.StatusOK},
+		{name: "bad signature", header: middlewares.SignRateLimitBypass("another secret, 32 characters ok", "GET", "/", time.Now().Add(time.Hour)), want: fiber.StatusTooManyRequests},
+		{name: "malformed", header: "tomorrow.abc", want: fiber.StatusTooManyRequests},
+		{name: "expired", header: sign("GET", "/", -time.Minute), want: fiber.StatusTooManyRequests},
+		{name: "beyond the max TTL", header: sign("GET", "/", 3*time.Hour), want: fiber.StatusTooManyRequests},
+		{name: "other method", header: sign("POST", "/", time.Hour), want: fiber.StatusTooManyRequests},
+		{name: "other path", header: sign("GET", "/v1/users", time.Hour), want: fiber.StatusTooManyRequests},
+		{name: "allowed network", client: "10.1.2.3", want: fiber.StatusOK},
+		{name: "other network", client: "192.0.2.1", want: fiber.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := testkit.NewApp(t,
+				testkit.WithConfig("TRUSTED_PROXIES", "0.0.0.0"),
+				testkit.WithConfig("RATE_LIMIT", "1"),
+				testkit.WithConfig("RATE_LIMIT_BYPASS_SECRET", secret),
+				testkit.WithConfig("RATE_LIMIT_BYPASS_MAX_TTL", "2h"),
+				testkit.WithConfig("RATE_LIMIT_BYPASS_CIDRS", "10.0.0.0/8"),
+			)
+
+			// The first request spends the limit of the client
+			var status int
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest("GET", "/", nil)
+				if tt.client != "" {
+					req.Header.Set("X-Forwarded-For", tt.client)
+				}
+				if tt.header != "" {
+					req.Header.Set(middlewares.HeaderRateLimitBypass, tt.header)
+				}
+				resp, err := app.Test(req, -1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				status = resp.StatusCode
+			}
+			if status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitBypassProduction(t *testing.T) {
+	values := map[string]string{
+		"ENV":                "production",
+		"HTTP_PORT":          "8000",
+		"APP_NAME":           "Stream - User Service",
+		"SERVICE_NAME":       "user-service",
+		"REDIS_HOST":         "localhost",
+		"DATABASE_HOST":      "localhost",
+		"DATABASE_NAME":      "test",
+		"DATABASE_USER":      "test",
+		"CORS_ALLOW_ORIGINS": "https://example.com",
+	}
+	if _, err := config.LoadLayers(config.MapLayer(config.SourceEnv, values)); err != nil {
+		t.Fatalf("production config without bypass: %v", err)
+	}
+
+	for env, value := range map[string]string{
+		"RATE_LIMIT_BYPASS_SECRET": "0123456789abcdef0123456789abcdef",
+		"RATE_LIMIT_BYPASS_CIDRS":  "10.0.0.0/8",
+	} {
+		values[env] = value
+		_, err := config.LoadLayers(config.MapLayer(config.SourceEnv, values))
+		if err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("production config with %s: error %v, want it refused", env, err)
+		}
+		delete(values, env)
+	}
+}
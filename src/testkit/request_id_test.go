@@ -0,0 +1,74 @@
+package testkit_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequestID(t *testing.T) {
+	app := testkit.NewApp(t)
+
+	tests := []struct {
+		name        string
+		requestID   string
+		traceparent string
+		want        string
+	}{
+		{name: "inbound", requestID: "req-42", want: "req-42"},
+		{name: "traceparent", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "inbound before traceparent", requestID: "req-42", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "req-42"},
+		{name: "invalid replaced", requestID: "bad\nid"},
+		{name: "generated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := resp.Header.Get("X-Request-ID")
+			if tt.want != "" && got != tt.want {
+				t.Errorf("X-Request-ID = %q, want %q", got, tt.want)
+			}
+			if got == "" || got == tt.requestID && tt.want == "" {
+				t.Errorf("X-Request-ID = %q, want a new ID", got)
+			}
+		})
+	}
+}
+
+func TestErrorBodyRequestID(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithConfig("RATE_LIMIT", "1"))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", "req-42")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			continue
+		}
+
+		var body fiber.Map
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusTooManyRequests || body["message"] != "rate limit exceeded" || body["request_id"] != "req-42" {
+			t.Errorf("status %d body %v, want a 429 carrying the request ID", resp.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package testkit
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/app/http_server"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/config"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/repositories"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/routes"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type (
+	// Server is a HttpServer whose routes are served from the in-memory fakes,
+	// the fakes are exposed to arrange and assert the tests
+	Server struct {
+		*http_server.HttpServer
+		Cache *Cache
+		Users *UserRepository
+		Db    *DbRepository
+	}
+	Option func(*options)
+
+	options struct {
+		values map[string]string
+		users  []models.User
+	}
+)
+
+// Config values of the test server, keyed by environment variable name. The
+// environment of the process and the config files are not read.
+var defaultValues = map[string]string{
+	"ENV":                        "local",
+	"HTTP_PORT":                  "8000",
+	"APP_NAME":                   "Stream - User Service",
+	"SERVICE_NAME":               "user-service",
+	"FIBER_PREFORK":              "false",
+	"OPENAPI_VALIDATE_RESPONSES": "true",
+	"REDIS_HOST":                 "localhost",
+	"DATABASE_HOST":              "localhost",
+	"DATABASE_NAME":              "test",
+	"DATABASE_USER":              "test",
+}
+
+// WithConfig set a config value, e.g. WithConfig("RATE_LIMIT", "5")
+func WithConfig(env string, value string) Option {
+	return func(o *options) {
+		o.values[env] = value
+	}
+}
+
+// WithUsers store the users before the server is created, they get the ids 1,
+// 2, ... in order
+func WithUsers(users ...models.User) Option {
+	return func(o *options) {
+		o.users = append(o.users, users...)
+	}
+}
+
+// NewServer return a server with every HTTP, gRPC and admin route registered,
+// the admin server is Admin() when ADMIN_PORT is set and the gRPC services are
+// served by DialGRPC when GRPC_ENABLED is set. The test fails when the config
+// is invalid
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	o := &options{values: map[string]string{}}
+	for env, value := range defaultValues {
+		o.values[env] = value
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg, err := config.LoadLayers(config.MapLayer(config.SourceEnv, o.values))
+	if err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+	cfg.Fiber.Middleware.Logger.Output = io.Discard
+
+	s := &Server{
+		Cache: NewCache(),
+		Users: NewUserRepository(o.users...),
+		Db:    NewDbRepository(),
+	}
+	s.HttpServer = http_server.NewHttpServer(cfg, nil, s.Cache, nil, nil)
+	repos := &repositories.Repositories{
+		Db:   s.Db,
+		User: s.Users,
+	}
+	routes.HTTPRoutes(s.HttpServer, repos)
+	routes.GRPCRoutes(s.HttpServer, repos)
+	routes.AdminRoutes(s.HttpServer, repos)
+
+	return s
+}
+
+// NewApp return a ready Fiber app for app.Test()
+func NewApp(t testing.TB, opts ...Option) *fiber.App {
+	t.Helper()
+	return NewServer(t, opts...).App()
+}
+
+// DialGRPC serve the gRPC services in memory and return a client connection
+// to them, both are closed at the end of the test. GRPC_ENABLED must be set.
+func (s *Server) DialGRPC(t testing.TB) *grpc.ClientConn {
+	t.Helper()
+
+	if s.GRPC() == nil {
+		t.Fatal("testkit: GRPC_ENABLED is not set")
+	}
+	listener := bufconn.Listen(1 << 20)
+	go s.GRPC().Serve(listener)
+	t.Cleanup(s.GRPC().Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("testkit: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
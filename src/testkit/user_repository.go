@@ -0,0 +1,200 @@
+package testkit
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/pkg/database"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"gorm.io/gorm"
+)
+
+// UserRepository is an in-memory repositories.UserRepository, it returns the
+// same errors as the database one (gorm.ErrRecordNotFound for unknown users)
+// and honours the context deadline like a query would
+type UserRepository struct {
+	mu     sync.Mutex
+	users  map[uint]models.User
+	nextID uint
+	err    error
+	delay  time.Duration
+}
+
+func NewUserRepository(users ...models.User) *UserRepository {
+	r := &UserRepository{users: map[uint]models.User{}}
+	for _, user := range users {
+		r.CreateUser(context.Background(), &user)
+	}
+	return r
+}
+
+func (r *UserRepository) GetUserPaginate(ctx context.Context, pagination database.Pagination, search string) (*database.Pagination, error) {
+	if err := r.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// Same as the LIKE query, case sensitive
+	users := make([]models.User, 0, len(r.users))
+	for _, user := range r.sorted() {
+		if search == "" ||
+			strings.Contains(user.Email, search) ||
+			strings.Contains(user.FirstName, search) ||
+			strings.Contains(user.LastName, search) {
+			users = append(users, user)
+		}
+	}
+
+	pagination.TotalRows = int64(len(users))
+	pagination.TotalPages = int(math.Ceil(float64(len(users)) / float64(pagination.GetLimit())))
+	pagination.GetSort()
+
+	offset := min(pagination.GetOffset(), len(users))
+	end := min(offset+pagination.GetLimit(), len(users))
+	pagination.Data = users[offset:end]
+
+	return &pagination, nil
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int) (models.User, error) {
+	if err := r.wait(ctx); err != nil {
+		return models.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return models.User{}, r.err
+	}
+
+	user, ok := r.users[uint(id)]
+	if !ok {
+		return models.User{}, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	r.nextID++
+	now := time.Now()
+	user.ID = r.nextID
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *UserRepository) UpdateUser(ctx context.Context, id int, user *models.User) error {
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	existUser, ok := r.users[uint(id)]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	existUser.FirstName = user.FirstName
+	existUser.LastName = user.LastName
+	existUser.Email = user.Email
+	existUser.UpdatedAt = time.Now()
+	r.users[existUser.ID] = existUser
+	return nil
+}
+
+// DeleteUser remove the user, deleting an unknown user is not an error like
+// with the database
+func (r *UserRepository) DeleteUser(ctx context.Context, id int) error {
+	if err := r.wait(ctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	delete(r.users, uint(id))
+	return nil
+}
+
+// Fail make every call return err, e.g. to simulate a failing query, or
+// succeed again with nil
+func (r *UserRepository) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Delay make every call take d, or until the context is done, e.g. to
+// simulate a slow query
+func (r *UserRepository) Delay(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delay = d
+}
+
+// Users return the stored users ordered by id
+func (r *UserRepository) Users() []models.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sorted()
+}
+
+func (r *UserRepository) wait(ctx context.Context) error {
+	r.mu.Lock()
+	delay := r.delay
+	r.mu.Unlock()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *UserRepository) sorted() []models.User {
+	users := make([]models.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+	return users
+}
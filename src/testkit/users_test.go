@@ -0,0 +1,364 @@
+package testkit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+)
+
+var users = []models.User{
+	{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"},
+	{FirstName: "Alan", LastName: "Turing", Email: "alan@example.com"},
+	{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com"},
+}
+
+type (
+	userPage struct {
+		Limit      int           `json:"limit"`
+		Page       int           `json:"page"`
+		TotalRows  int64         `json:"total_rows"`
+		TotalPages int           `json:"total_pages"`
+		Data       []models.User `json:"data"`
+	}
+	validationError struct {
+		Message    string `json:"message"`
+		Violations []struct {
+			In     string `json:"in"`
+			Name   string `json:"name"`
+			Reason string `json:"reason"`
+		} `json:"violations"`
+	}
+)
+
+// do send a request to the app and decode the JSON response body into out
+func do(t *testing.T, app *fiber.App, method string, path string, body interface{}, out interface{}) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: cannot decode the response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestGetUsers(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithUsers(users...))
+	app := s.App()
+
+	var page userPage
+	if status := do(t, app, "GET", "/users", nil, &page); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+	if page.TotalRows != 3 || page.TotalPages != 1 || len(page.Data) != 3 {
+		t.Fatalf("page = %+v, want the 3 users on 1 page", page)
+	}
+	if page.Page != 1 || page.Limit != 20 {
+		t.Errorf("page %d limit %d, want the defaults 1 and 20", page.Page, page.Limit)
+	}
+}
+
+func TestGetUsersPaginate(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	var page userPage
+	do(t, app, "GET", "/users?page=2&limit=2", nil, &page)
+	if page.TotalRows != 3 || page.TotalPages != 2 {
+		t.Errorf("total rows %d pages %d, want 3 and 2", page.TotalRows, page.TotalPages)
+	}
+	if len(page.Data) != 1 || page.Data[0].Email != "grace@example.com" {
+		t.Errorf("data = %+v, want the third user only", page.Data)
+	}
+}
+
+func TestGetUsersSearch(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	var page userPage
+	do(t, app, "GET", "/users?search=Turing", nil, &page)
+	if len(page.Data) != 1 || page.Data[0].LastName != "Turing" {
+		t.Errorf("data = %+v, want Alan Turing only", page.Data)
+	}
+}
+
+func TestGetUsersCached(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithUsers(users...))
+	app := s.App()
+
+	do(t, app, "GET", "/users", nil, nil)
+	if keys := s.Cache.Keys(); len(keys) != 1 || keys[0] != "GetUsers_1_20" {
+		t.Fatalf("cache keys = %v, want [GetUsers_1_20]", keys)
+	}
+
+	// Served from the cache, the repository is not called again
+	s.Users.Fail(errors.New("query failed"))
+	var page userPage
+	if status := do(t, app, "GET", "/users", nil, &page); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+	if len(page.Data) != 3 {
+		t.Errorf("cached data = %+v, want the 3 users", page.Data)
+	}
+}
+
+func TestGetUsersTimeout(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithConfig("REQUEST_TIMEOUT", "50ms"))
+	s.Users.Delay(time.Second)
+
+	start := time.Now()
+	var body fiber.Map
+	if status := do(t, s.App(), "GET", "/users", nil, &body); status != fiber.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusGatewayTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("answered after %s, want the query cancelled at the deadline", elapsed)
+	}
+	if body["message"] == nil {
+		t.Errorf("body = %v, want an error message", body)
+	}
+}
+
+func TestGetUsersRouteTimeout(t *testing.T) {
+	s := testkit.NewServer(t,
+		testkit.WithConfig("REQUEST_TIMEOUT", "50ms"),
+		testkit.WithConfig("REQUEST_TIMEOUT_ROUTES", "GET /users:1s"),
+	)
+	s.Users.Delay(100 * time.Millisecond)
+
+	if status := do(t, s.App(), "GET", "/users", nil, nil); status != fiber.StatusOK {
+		t.Errorf("status = %d, want %d with the longer route timeout", status, fiber.StatusOK)
+	}
+	if status := do(t, s.App(), "GET", "/users/1", nil, nil); status != fiber.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d with the default timeout", status, fiber.StatusGatewayTimeout)
+	}
+}
+
+func TestVersionedRouteTimeout(t *testing.T) {
+	s := testkit.NewServer(t,
+		testkit.WithUsers(users...),
+		testkit.WithConfig("REQUEST_TIMEOUT", "50ms"),
+		testkit.WithConfig("REQUEST_TIMEOUT_ROUTES", "GET /users/:id:1s,GET /v2/users:1s"),
+	)
+	s.Users.Delay(100 * time.Millisecond)
+
+	// An unversioned route applies to every version, a versioned one to that
+	// version only, negotiated or not. Each request reads other users so the
+	// cache does not answer it.
+	tests := []struct {
+		name       string
+		path       string
+		version    string
+		wantStatus int
+	}{
+		{name: "unversioned route", path: "/users/1", wantStatus: fiber.StatusOK},
+		{name: "unversioned route, versioned path", path: "/v2/users/2", wantStatus: fiber.StatusOK},
+		{name: "unversioned route, negotiated version", path: "/users/3", version: "1", wantStatus: fiber.StatusOK},
+		{name: "versioned route", path: "/v2/users?page=1", wantStatus: fiber.StatusOK},
+		{name: "versioned route, negotiated version", path: "/users?page=2", version: "2", wantStatus: fiber.StatusOK},
+		{name: "versioned route, other version", path: "/v1/users?page=3", wantStatus: fiber.StatusGatewayTimeout},
+		{name: "versioned route, unversioned path", path: "/users?page=4", wantStatus: fiber.StatusGatewayTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.version != "" {
+				req.Header.Set("API-Version", tt.version)
+			}
+			resp, err := s.App().Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGetUsersQueryFailed(t *testing.T) {
+	s := testkit.NewServer(t)
+	s.Users.Fail(errors.New("query failed"))
+
+	if status := do(t, s.App(), "GET", "/users", nil, nil); status != fiber.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", status, fiber.StatusInternalServerError)
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	var body struct {
+		Data models.User `json:"data"`
+	}
+	if status := do(t, app, "GET", "/users/2", nil, &body); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+	if body.Data.ID != 2 || body.Data.Email != "alan@example.com" {
+		t.Errorf("user = %+v, want Alan Turing", body.Data)
+	}
+}
+
+func TestGetUserUnknown(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	if status := do(t, app, "GET", "/users/42", nil, nil); status == fiber.StatusOK {
+		t.Errorf("status = %d, want an error", status)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithUsers(users...))
+	app := s.App()
+
+	// Cache the list, creating a user must flush it
+	do(t, app, "GET", "/users", nil, nil)
+
+	user := fiber.Map{"first_name": "Katherine", "last_name": "Johnson", "email": "katherine@example.com"}
+	var body fiber.Map
+	if status := do(t, app, "POST", "/users", user, &body); status != fiber.StatusCreated {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusCreated)
+	}
+	if body["code"] != "0" {
+		t.Errorf("body = %v, want code 0", body)
+	}
+
+	stored := s.Users.Users()
+	if len(stored) != 4 || stored[3].Email != "katherine@example.com" {
+		t.Fatalf("stored users = %+v, want Katherine Johnson added", stored)
+	}
+	if keys := s.Cache.Keys(); len(keys) != 0 {
+		t.Errorf("cache keys = %v, want the users cache flushed", keys)
+	}
+
+	var page userPage
+	do(t, app, "GET", "/users", nil, &page)
+	if page.TotalRows != 4 {
+		t.Errorf("total rows = %d, want 4", page.TotalRows)
+	}
+}
+
+func TestCreateUserInvalid(t *testing.T) {
+	s := testkit.NewServer(t)
+
+	user := fiber.Map{"first_name": "Katherine", "email": "not an email"}
+	var errs validationError
+	if status := do(t, s.App(), "POST", "/users", user, &errs); status != fiber.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusBadRequest)
+	}
+
+	failed := map[string]string{}
+	for _, violation := range errs.Violations {
+		failed[violation.In+" "+violation.Name] = violation.Reason
+	}
+	if failed["body /last_name"] != "is required" || failed["body /email"] != "must be a valid email" || len(failed) != 2 {
+		t.Errorf("violations = %+v, want last_name required and email invalid", errs.Violations)
+	}
+	if stored := s.Users.Users(); len(stored) != 0 {
+		t.Errorf("stored users = %+v, want none", stored)
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithUsers(users...))
+	app := s.App()
+
+	// Cache the user, updating it must flush it
+	do(t, app, "GET", "/users/3", nil, nil)
+
+	user := fiber.Map{"first_name": "Grace", "last_name": "Brewster Hopper", "email": "grace.hopper@example.com"}
+	if status := do(t, app, "PUT", "/users/3", user, nil); status != fiber.StatusOK {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+	}
+
+	var body struct {
+		Data models.User `json:"data"`
+	}
+	do(t, app, "GET", "/users/3", nil, &body)
+	if body.Data.LastName != "Brewster Hopper" || body.Data.Email != "grace.hopper@example.com" {
+		t.Errorf("user = %+v, want the updated values", body.Data)
+	}
+	if first := s.Users.Users()[0]; first.Email != "ada@example.com" {
+		t.Errorf("first user = %+v, want it untouched", first)
+	}
+}
+
+func TestUpdateUserUnknown(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	user := fiber.Map{"first_name": "Grace", "last_name": "Hopper", "email": "grace@example.com"}
+	if status := do(t, app, "PUT", "/users/42", user, nil); status == fiber.StatusOK {
+		t.Errorf("status = %d, want an error", status)
+	}
+}
+
+func TestUpdateUserInvalid(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	if status := do(t, app, "PUT", "/users/1", fiber.Map{"email": "ada@example.com"}, nil); status != fiber.StatusBadRequest {
+		t.Errorf("status = %d, want %d", status, fiber.StatusBadRequest)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	s := testkit.NewServer(t, testkit.WithUsers(users...))
+	app := s.App()
+
+	do(t, app, "GET", "/users", nil, nil)
+
+	if status := do(t, app, "DELETE", "/users/1", nil, nil); status != fiber.StatusNoContent {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusNoContent)
+	}
+
+	var page userPage
+	do(t, app, "GET", "/users", nil, &page)
+	if page.TotalRows != 2 {
+		t.Errorf("total rows = %d, want 2 after the delete", page.TotalRows)
+	}
+	for _, user := range page.Data {
+		if user.ID == 1 {
+			t.Errorf("deleted user still listed: %+v", user)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s := testkit.NewServer(t)
+	app := s.App()
+
+	if status := do(t, app, "GET", "/health", nil, nil); status != fiber.StatusOK {
+		t.Errorf("status = %d, want %d", status, fiber.StatusOK)
+	}
+
+	s.Db.Fail(fiber.ErrServiceUnavailable)
+	if status := do(t, app, "GET", "/health", nil, nil); status != fiber.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d when the database is down", status, fiber.StatusServiceUnavailable)
+	}
+}
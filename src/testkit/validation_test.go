@@ -0,0 +1,114 @@
+package testkit_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/models"
+	"github.com/Stream-I-T-Consulting/stream-http-service-go/src/testkit"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequestValidation(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithUsers(users...))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		contentType    string
+		version        string
+		wantStatus     int
+		wantViolations []string
+	}{
+		{name: "path", method: "GET", path: "/v1/users/abc", wantStatus: fiber.StatusBadRequest, wantViolations: []string{"path id must be an integer"}},
+		{name: "query", method: "GET", path: "/v1/users?page=first&limit=ten", wantStatus: fiber.StatusBadRequest, wantViolations: []string{"query page must be an integer", "query limit must be an integer"}},
+		{name: "page below 1", method: "GET", path: "/v1/users?page=0", wantStatus: fiber.StatusBadRequest, wantViolations: []string{"query page must be 1 or more"}},
+		{name: "limit below 1", method: "GET", path: "/v1/users?limit=0", wantStatus: fiber.StatusBadRequest, wantViolations: []string{"query limit must be 1 or more"}},
+		{name: "limit above the maximum", method: "GET", path: "/v1/users?page=-1&limit=1000", wantStatus: fiber.StatusBadRequest, wantViolations: []string{"query page must be 1 or more", "query limit must be 100 or less"}},
+		{name: "id below 1", method: "GET", path: "/v1/users/0", wantStatus: fiber.StatusBadRequest, wantViolations: []string{"path id must be 1 or more"}},
+		{name: "negotiated version", method: "GET", path: "/users/abc", version: "2", wantStatus: fiber.StatusBadRequest, wantViolations: []string{"path id must be an integer"}},
+		{name: "body", method: "POST", path: "/v1/users", body: `{"first_name":"","last_name":7,"email":"ada@example.com"}`, contentType: fiber.MIMEApplicationJSON, wantStatus: fiber.StatusBadRequest, wantViolations: []string{"body /first_name must not be empty", "body /last_name must be a string"}},
+		{name: "malformed body", method: "PUT", path: "/v1/users/1", body: `{"first_name":`, contentType: fiber.MIMEApplicationJSON, wantStatus: fiber.StatusBadRequest},
+		{name: "missing body", method: "POST", path: "/v1/users", contentType: fiber.MIMEApplicationJSON, wantStatus: fiber.StatusBadRequest, wantViolations: []string{"body  is required"}},
+		{name: "content type", method: "POST", path: "/v1/users", body: "first_name=Ada", contentType: fiber.MIMEApplicationForm, wantStatus: fiber.StatusUnsupportedMediaType, wantViolations: []string{"header Content-Type must be application/json"}},
+		{name: "valid", method: "GET", path: "/v1/users?page=1&search=ada", wantStatus: fiber.StatusOK},
+		{name: "largest page", method: "GET", path: "/v1/users?page=3&limit=100", wantStatus: fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			}
+			if tt.version != "" {
+				req.Header.Set("API-Version", tt.version)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			if tt.wantStatus == fiber.StatusOK {
+				return
+			}
+			var errs validationError
+			if err := json.NewDecoder(resp.Body).Decode(&errs); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, violation := range errs.Violations {
+				got = append(got, violation.In+" "+violation.Name+" "+violation.Reason)
+			}
+			if len(errs.Violations) == 0 || tt.wantViolations != nil && strings.Join(got, "|") != strings.Join(tt.wantViolations, "|") {
+				t.Errorf("violations = %q, want %q", got, tt.wantViolations)
+			}
+		})
+	}
+}
+
+func TestRequestValidationDisabled(t *testing.T) {
+	app := testkit.NewApp(t, testkit.WithConfig("OPENAPI_VALIDATE_REQUESTS", "false"), testkit.WithUsers(users...))
+
+	// The handlers check their parameters on their own
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{path: "/v2/users/abc", wantStatus: fiber.StatusBadRequest, wantMessage: "id must be a positive integer"},
+		{path: "/v2/users/0", wantStatus: fiber.StatusBadRequest, wantMessage: "id must be a positive integer"},
+		{path: "/v1/users?page=0", wantStatus: fiber.StatusBadRequest, wantMessage: "page must be 1 or more"},
+		{path: "/v1/users?limit=0", wantStatus: fiber.StatusBadRequest, wantMessage: "limit must be between 1 and 100"},
+		{path: "/v1/users?limit=101", wantStatus: fiber.StatusBadRequest, wantMessage: "limit must be between 1 and 100"},
+		{path: "/v1/users?page=2&limit=100", wantStatus: fiber.StatusOK},
+		{path: "/v2/users/42", wantStatus: fiber.StatusNotFound, wantMessage: "user not found"},
+	}
+	for _, tt := range tests {
+		status, message := authorized(t, app, "GET", tt.path, "", "")
+		if status != tt.wantStatus || message != tt.wantMessage {
+			t.Errorf("GET %s = %d %q, want %d %q from the handler", tt.path, status, message, tt.wantStatus, tt.wantMessage)
+		}
+	}
+}
+
+func TestResponseValidation(t *testing.T) {
+	s := testkit.NewServer(t)
+	s.GET("/v1/drift", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"id": "one", "email": 7})
+	}).Response(fiber.StatusOK, models.User{}, "")
+
+	var errs validationError
+	if status := do(t, s.App(), "GET", "/v1/drift", nil, &errs); status != fiber.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", status, fiber.StatusInternalServerError)
+	}
+	if len(errs.Violations) != 2 {
+		t.Errorf("violations = %+v, want the id and the email", errs.Violations)
+	}
+}